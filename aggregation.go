@@ -0,0 +1,517 @@
+package hbase
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/cugbliwei/go-hbase/proto"
+	pb "github.com/golang/protobuf/proto"
+)
+
+const aggregate_service = "AggregateService"
+
+// ColumnInterpreter tells the AggregateImplementation coprocessor how to
+// read the aggregated column, like Java's ColumnInterpreter. Values are
+// combined on the client as exact rationals.
+type ColumnInterpreter interface {
+	// ClassName is the Java interpreter the region servers load.
+	ClassName() string
+	// Params are the interpreter specific bytes of the request, nil for none.
+	Params() []byte
+	// DecodeMsg decodes a partial result sent by a region.
+	DecodeMsg(b []byte) (*big.Rat, error)
+	// DecodeCell decodes a stored value, ok is false when it is not a
+	// value of the interpreter, such cells are skipped like the server does.
+	DecodeCell(b []byte) (v *big.Rat, ok bool)
+}
+
+type longColumnInterpreter struct{}
+
+// NewLongColumnInterpreter reads values written with Java's Bytes.toBytes(long).
+func NewLongColumnInterpreter() ColumnInterpreter {
+	return longColumnInterpreter{}
+}
+
+func (longColumnInterpreter) ClassName() string {
+	return "org.apache.hadoop.hbase.client.coprocessor.LongColumnInterpreter"
+}
+
+func (longColumnInterpreter) Params() []byte {
+	return nil
+}
+
+func (longColumnInterpreter) DecodeMsg(b []byte) (*big.Rat, error) {
+	m := &proto.LongMsg{}
+	if err := pb.Unmarshal(b, m); err != nil {
+		return nil, err
+	}
+
+	return new(big.Rat).SetInt64(m.GetLongMsg()), nil
+}
+
+func (longColumnInterpreter) DecodeCell(b []byte) (*big.Rat, bool) {
+	if len(b) != 8 {
+		return nil, false
+	}
+
+	v, _ := DecodeInt64(b)
+
+	return new(big.Rat).SetInt64(v), true
+}
+
+type doubleColumnInterpreter struct{}
+
+// NewDoubleColumnInterpreter reads values written with Java's Bytes.toBytes(double).
+func NewDoubleColumnInterpreter() ColumnInterpreter {
+	return doubleColumnInterpreter{}
+}
+
+func (doubleColumnInterpreter) ClassName() string {
+	return "org.apache.hadoop.hbase.client.coprocessor.DoubleColumnInterpreter"
+}
+
+func (doubleColumnInterpreter) Params() []byte {
+	return nil
+}
+
+func (doubleColumnInterpreter) DecodeMsg(b []byte) (*big.Rat, error) {
+	m := &proto.DoubleMsg{}
+	if err := pb.Unmarshal(b, m); err != nil {
+		return nil, err
+	}
+
+	r := new(big.Rat).SetFloat64(m.GetDoubleMsg())
+	if r == nil {
+		return nil, fmt.Errorf("Aggregate is not finite: %v", m.GetDoubleMsg())
+	}
+
+	return r, nil
+}
+
+func (doubleColumnInterpreter) DecodeCell(b []byte) (*big.Rat, bool) {
+	if len(b) != 8 {
+		return nil, false
+	}
+
+	v, _ := DecodeFloat64(b)
+	r := new(big.Rat).SetFloat64(v)
+
+	return r, r != nil
+}
+
+type bigDecimalColumnInterpreter struct{}
+
+// NewBigDecimalColumnInterpreter reads values written with Java's Bytes.toBytes(BigDecimal).
+func NewBigDecimalColumnInterpreter() ColumnInterpreter {
+	return bigDecimalColumnInterpreter{}
+}
+
+func (bigDecimalColumnInterpreter) ClassName() string {
+	return "org.apache.hadoop.hbase.client.coprocessor.BigDecimalColumnInterpreter"
+}
+
+func (bigDecimalColumnInterpreter) Params() []byte {
+	return nil
+}
+
+func (bigDecimalColumnInterpreter) DecodeMsg(b []byte) (*big.Rat, error) {
+	m := &proto.BigDecimalMsg{}
+	if err := pb.Unmarshal(b, m); err != nil {
+		return nil, err
+	}
+
+	d, err := DecodeBigDecimal(m.GetBigdecimalMsg())
+	if err != nil {
+		return nil, err
+	}
+
+	return d.Rat(), nil
+}
+
+func (bigDecimalColumnInterpreter) DecodeCell(b []byte) (*big.Rat, bool) {
+	d, err := DecodeBigDecimal(b)
+	if err != nil {
+		return nil, false
+	}
+
+	return d.Rat(), true
+}
+
+// AggregationClient computes aggregates of a column in the region servers
+// through the AggregateImplementation coprocessor, which has to be loaded
+// for the table. The scan of each call selects the range, the column and
+// the filter; it must name exactly one family, except for RowCount.
+type AggregationClient struct {
+	interpreter ColumnInterpreter
+}
+
+func NewAggregationClient(interpreter ColumnInterpreter) *AggregationClient {
+	return &AggregationClient{
+		interpreter: interpreter,
+	}
+}
+
+// Max returns the largest value, nil when no cell matched.
+func (a *AggregationClient) Max(s *Scan) (*big.Rat, error) {
+	return a.extreme(s, "GetMax", 1)
+}
+
+// Min returns the smallest value, nil when no cell matched.
+func (a *AggregationClient) Min(s *Scan) (*big.Rat, error) {
+	return a.extreme(s, "GetMin", -1)
+}
+
+func (a *AggregationClient) extreme(s *Scan, method string, sign int) (*big.Rat, error) {
+	parts, err := a.exec(s, method)
+	if err != nil {
+		return nil, err
+	}
+
+	var tbr *big.Rat
+	for _, p := range parts {
+		if len(p.GetFirstPart()) == 0 {
+			continue
+		}
+
+		v, err := a.interpreter.DecodeMsg(p.GetFirstPart()[0])
+		if err != nil {
+			return nil, err
+		}
+
+		if tbr == nil || v.Cmp(tbr) == sign {
+			tbr = v
+		}
+	}
+
+	return tbr, nil
+}
+
+// Sum returns the sum of the values, nil when no cell matched.
+func (a *AggregationClient) Sum(s *Scan) (*big.Rat, error) {
+	parts, err := a.exec(s, "GetSum")
+	if err != nil {
+		return nil, err
+	}
+
+	var tbr *big.Rat
+	for _, p := range parts {
+		if len(p.GetFirstPart()) == 0 {
+			continue
+		}
+
+		v, err := a.interpreter.DecodeMsg(p.GetFirstPart()[0])
+		if err != nil {
+			return nil, err
+		}
+
+		if tbr == nil {
+			tbr = new(big.Rat)
+		}
+		tbr.Add(tbr, v)
+	}
+
+	return tbr, nil
+}
+
+// RowCount returns the number of rows of the scan, which may name no
+// family. Without a column and filter the server reads only the first
+// cell of each row.
+func (a *AggregationClient) RowCount(s *Scan) (int64, error) {
+	parts, err := a.exec(s, "GetRowNum")
+	if err != nil {
+		return 0, err
+	}
+
+	var tbr int64
+	for _, p := range parts {
+		if len(p.GetFirstPart()) == 0 {
+			continue
+		}
+
+		n, err := decodeCount(p.GetFirstPart()[0])
+		if err != nil {
+			return 0, err
+		}
+		tbr += n
+	}
+
+	return tbr, nil
+}
+
+// Avg returns the mean of the values, NaN when no cell matched.
+func (a *AggregationClient) Avg(s *Scan) (float64, error) {
+	parts, err := a.exec(s, "GetAvg")
+	if err != nil {
+		return 0, err
+	}
+
+	sum, _, n, err := a.moments(parts)
+	if err != nil {
+		return 0, err
+	}
+
+	if n == 0 {
+		return math.NaN(), nil
+	}
+
+	avg, _ := new(big.Rat).Quo(sum, new(big.Rat).SetInt64(n)).Float64()
+
+	return avg, nil
+}
+
+// Std returns the population standard deviation of the values, NaN when
+// no cell matched.
+func (a *AggregationClient) Std(s *Scan) (float64, error) {
+	parts, err := a.exec(s, "GetStd")
+	if err != nil {
+		return 0, err
+	}
+
+	sum, sumSq, n, err := a.moments(parts)
+	if err != nil {
+		return 0, err
+	}
+
+	if n == 0 {
+		return math.NaN(), nil
+	}
+
+	count := new(big.Rat).SetInt64(n)
+	avg := new(big.Rat).Quo(sum, count)
+	variance := new(big.Rat).Quo(sumSq, count)
+	variance.Sub(variance, avg.Mul(avg, avg))
+
+	v, _ := variance.Float64()
+
+	return math.Sqrt(math.Max(v, 0)), nil
+}
+
+// moments adds up the sums, sums of squares and counts of GetAvg and
+// GetStd responses, GetAvg has no sums of squares.
+func (a *AggregationClient) moments(parts []*proto.AggregateResponse) (*big.Rat, *big.Rat, int64, error) {
+	sum := new(big.Rat)
+	sumSq := new(big.Rat)
+	var n int64
+
+	for _, p := range parts {
+		if len(p.GetFirstPart()) == 0 {
+			continue
+		}
+
+		v, err := a.interpreter.DecodeMsg(p.GetFirstPart()[0])
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		sum.Add(sum, v)
+
+		if len(p.GetFirstPart()) > 1 {
+			v, err := a.interpreter.DecodeMsg(p.GetFirstPart()[1])
+			if err != nil {
+				return nil, nil, 0, err
+			}
+			sumSq.Add(sumSq, v)
+		}
+
+		c, err := decodeCount(p.GetSecondPart())
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		n += c
+	}
+
+	return sum, sumSq, n, nil
+}
+
+// Median returns the median of the values, nil when no cell matched.
+// Like Java's AggregationClient each value is weighted by itself.
+func (a *AggregationClient) Median(s *Scan) (*big.Rat, error) {
+	return a.median(s, nil)
+}
+
+// WeightedMedian returns the median of the values, each weighted by the
+// value of weightQualifier in the same family and row. The server takes
+// the first qualifier in byte order as the value and the last as the
+// weight, so weightQualifier must sort after the value qualifier.
+func (a *AggregationClient) WeightedMedian(s *Scan, weightQualifier []byte) (*big.Rat, error) {
+	if len(s.families) != 1 || len(s.qualifiers[0]) != 1 {
+		return nil, fmt.Errorf("Weighted median needs a scan of exactly one column")
+	}
+
+	valueQualifier := s.qualifiers[0][0]
+	if bytes.Compare(weightQualifier, valueQualifier) <= 0 {
+		return nil, fmt.Errorf("Weight qualifier must sort after the value qualifier [value: %q] [weight: %q]", valueQualifier, weightQualifier)
+	}
+
+	// the caller's scan is left as is
+	ws := *s
+	ws.families = [][]byte{s.families[0]}
+	ws.qualifiers = [][][]byte{{valueQualifier, weightQualifier}}
+
+	return a.median(&ws, weightQualifier)
+}
+
+// median runs in two phases: the regions report the sums of their values
+// (and weights), which locate the region holding the median, then that
+// region is scanned until the running sum passes half of the total.
+func (a *AggregationClient) median(s *Scan, weightQualifier []byte) (*big.Rat, error) {
+	if len(s.families) != 1 || len(s.qualifiers[0]) == 0 {
+		return nil, fmt.Errorf("Median needs a scan of one column")
+	}
+
+	regions, err := a.execRegions(s, "GetMedian")
+	if err != nil {
+		return nil, err
+	}
+
+	weighted := weightQualifier != nil
+
+	total := new(big.Rat)
+	sums := make([]*big.Rat, len(regions))
+	for i, r := range regions {
+		sums[i] = new(big.Rat)
+
+		p := r.Response.(*proto.AggregateResponse)
+		pos := 0
+		if weighted {
+			pos = 1
+		}
+		if len(p.GetFirstPart()) <= pos {
+			continue
+		}
+
+		v, err := a.interpreter.DecodeMsg(p.GetFirstPart()[pos])
+		if err != nil {
+			return nil, err
+		}
+
+		sums[i] = v
+		total.Add(total, v)
+	}
+
+	if total.Sign() == 0 {
+		return nil, nil
+	}
+
+	half := new(big.Rat).Quo(total, big.NewRat(2, 1))
+
+	// find the region where the running sum passes half
+	moving := new(big.Rat)
+	startRow := s.StartRow
+	for i, r := range regions {
+		next := new(big.Rat).Add(moving, sums[i])
+		if next.Cmp(half) > 0 {
+			if len(r.StartKey) > 0 && (startRow == nil || bytes.Compare(r.StartKey, startRow) > 0) {
+				startRow = r.StartKey
+			}
+			break
+		}
+		moving = next
+	}
+
+	scan := newScan(s.ctx, s.table, s.client)
+	scan.StartRow = startRow
+	scan.StopRow = s.StopRow
+	scan.families = s.families
+	scan.qualifiers = s.qualifiers
+	scan.timeRange = s.timeRange
+	scan.filter = s.filter
+	defer scan.Close()
+
+	family := string(s.families[0])
+	valueColumn := family + ":" + string(s.qualifiers[0][0])
+	weightColumn := family + ":" + string(weightQualifier)
+
+	var last *big.Rat
+	for {
+		row, err := scan.Next()
+		if err != nil {
+			return nil, err
+		}
+		if row == nil {
+			break
+		}
+
+		col, ok := row.Columns[valueColumn]
+		if !ok {
+			continue
+		}
+
+		value, ok := a.interpreter.DecodeCell(col.Value)
+		if !ok {
+			continue
+		}
+		last = value
+
+		weight := value
+		if weighted {
+			wcol, ok := row.Columns[weightColumn]
+			if !ok {
+				continue
+			}
+
+			if weight, ok = a.interpreter.DecodeCell(wcol.Value); !ok {
+				continue
+			}
+		}
+
+		moving.Add(moving, weight)
+		if moving.Cmp(half) > 0 {
+			return value, nil
+		}
+	}
+
+	return last, nil
+}
+
+// exec calls method on every region of the scan and returns the replies.
+func (a *AggregationClient) exec(s *Scan, method string) ([]*proto.AggregateResponse, error) {
+	regions, err := a.execRegions(s, method)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := make([]*proto.AggregateResponse, len(regions))
+	for i, r := range regions {
+		parts[i] = r.Response.(*proto.AggregateResponse)
+	}
+
+	return parts, nil
+}
+
+func (a *AggregationClient) execRegions(s *Scan, method string) ([]*CoprocessorResult, error) {
+	// only the row count can do without a family
+	if len(s.families) != 1 && !(method == "GetRowNum" && len(s.families) == 0) {
+		return nil, fmt.Errorf("Aggregation needs a scan of exactly one family, got %d", len(s.families))
+	}
+
+	req := &proto.AggregateRequest{
+		InterpreterClassName:     pb.String(a.interpreter.ClassName()),
+		Scan:                     s.scanProto(),
+		InterpreterSpecificBytes: a.interpreter.Params(),
+	}
+
+	results, err := s.client.CoprocessorExecRangeContext(s.ctx, s.table.String(), s.StartRow, s.StopRow,
+		aggregate_service, method, req, func() pb.Message { return &proto.AggregateResponse{} })
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range results {
+		if r.Err != nil {
+			return nil, fmt.Errorf("Aggregation failed [region: %s] [err: %s]", r.Region, r.Err)
+		}
+	}
+
+	return results, nil
+}
+
+// decodeCount reads a count sent as 8 bytes, like Java's ByteBuffer.putLong.
+func decodeCount(b []byte) (int64, error) {
+	if len(b) != 8 {
+		return 0, fmt.Errorf("Invalid count of %d bytes", len(b))
+	}
+
+	return int64(byte_order.Uint64(b)), nil
+}
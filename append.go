@@ -0,0 +1,91 @@
+package hbase
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/cugbliwei/go-hbase/proto"
+	pb "github.com/golang/protobuf/proto"
+)
+
+type Append struct {
+	mutationOptions
+
+	key        []byte
+	families   [][]byte
+	qualifiers [][][]byte
+	values     [][][]byte
+}
+
+func CreateNewAppend(key []byte) *Append {
+	return &Append{
+		key:        key,
+		families:   make([][]byte, 0),
+		qualifiers: make([][][]byte, 0),
+		values:     make([][][]byte, 0),
+	}
+}
+
+// AddValue appends value to the end of the cell stored at family:column.
+func (this *Append) AddValue(family, column, value []byte) {
+	pos := this.posOfFamily(family)
+
+	if pos == -1 {
+		this.families = append(this.families, family)
+		this.qualifiers = append(this.qualifiers, make([][]byte, 0))
+		this.values = append(this.values, make([][]byte, 0))
+
+		pos = this.posOfFamily(family)
+	}
+
+	this.qualifiers[pos] = append(this.qualifiers[pos], column)
+	this.values[pos] = append(this.values[pos], value)
+}
+
+func (this *Append) AddStringValue(family, column, value string) {
+	this.AddValue([]byte(family), []byte(column), []byte(value))
+}
+
+// SetTTL expires the written cells after ttl, in millisecond precision.
+func (this *Append) SetTTL(ttl time.Duration) {
+	this.setTTL(ttl)
+}
+
+func (this *Append) posOfFamily(family []byte) int {
+	for p, v := range this.families {
+		if bytes.Equal(family, v) {
+			return p
+		}
+	}
+	return -1
+}
+
+func (this *Append) row() []byte {
+	return this.key
+}
+
+func (this *Append) toProto() pb.Message {
+	p := &proto.MutationProto{
+		Row:        this.key,
+		MutateType: proto.MutationProto_APPEND.Enum(),
+	}
+
+	for i, family := range this.families {
+		cv := &proto.MutationProto_ColumnValue{
+			Family: family,
+		}
+
+		for j, _ := range this.qualifiers[i] {
+			cv.QualifierValue = append(cv.QualifierValue, &proto.MutationProto_ColumnValue_QualifierValue{
+				Qualifier: this.qualifiers[i][j],
+				Value:     this.values[i][j],
+			})
+		}
+
+		p.ColumnValue = append(p.ColumnValue, cv)
+	}
+
+	this.applyTo(p)
+
+	return p
+}
@@ -0,0 +1,151 @@
+package hbase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cugbliwei/go-hbase/proto"
+)
+
+// BatchResult is the outcome of one item of a batch. Batch results are
+// in the order of the submitted items.
+type BatchResult struct {
+	// the row read by a get, or returned by an increment or append
+	Row *ResultRow
+	Err error
+}
+
+func (c *Client) BatchGets(table string, gets []*Get) ([]*BatchResult, error) {
+	return c.BatchGetsContext(context.Background(), table, gets)
+}
+
+func (c *Client) BatchGetsContext(ctx context.Context, table string, gets []*Get) ([]*BatchResult, error) {
+	actions := make([]multiaction, len(gets))
+
+	for i, v := range gets {
+		actions[i] = multiaction{
+			row:    v.key,
+			action: v,
+			index:  i,
+		}
+	}
+
+	return c.batch(ctx, table, actions)
+}
+
+func (c *Client) BatchPuts(table string, puts []*Put) ([]*BatchResult, error) {
+	return c.BatchPutsContext(context.Background(), table, puts)
+}
+
+func (c *Client) BatchPutsContext(ctx context.Context, table string, puts []*Put) ([]*BatchResult, error) {
+	actions := make([]multiaction, len(puts))
+
+	for i, v := range puts {
+		actions[i] = multiaction{
+			row:    v.key,
+			action: v,
+			index:  i,
+		}
+	}
+
+	return c.batch(ctx, table, actions)
+}
+
+func (c *Client) BatchDeletes(table string, dels []*Delete) ([]*BatchResult, error) {
+	return c.BatchDeletesContext(context.Background(), table, dels)
+}
+
+func (c *Client) BatchDeletesContext(ctx context.Context, table string, dels []*Delete) ([]*BatchResult, error) {
+	actions := make([]multiaction, len(dels))
+
+	for i, v := range dels {
+		actions[i] = multiaction{
+			row:    v.key,
+			action: v,
+			index:  i,
+		}
+	}
+
+	return c.batch(ctx, table, actions)
+}
+
+// batch runs actions and lines their results up by index. The returned
+// error is only set when ctx is done, item failures are in the results.
+func (c *Client) batch(ctx context.Context, table string, actions []multiaction) ([]*BatchResult, error) {
+	tbr := make([]*BatchResult, len(actions))
+
+	ch := c.multiaction(ctx, ParseTableName(table), actions, true, 0)
+
+	for r := range ch {
+		rs, ok := r.(*proto.MultiResponse)
+		if !ok {
+			continue
+		}
+
+		for _, v := range rs.GetRegionActionResult() {
+			for _, roe := range v.GetResultOrException() {
+				i := int(roe.GetIndex())
+				if i >= len(tbr) {
+					continue
+				}
+
+				if e := roe.GetException(); e != nil {
+					tbr[i] = &BatchResult{
+						Err: fmt.Errorf("Action failed [exception: %s] [msg: %s]", e.GetName(), e.GetValue()),
+					}
+					continue
+				}
+
+				tbr[i] = &BatchResult{}
+				if res := roe.GetResult(); res != nil {
+					tbr[i].Row = newResultRow(res)
+				}
+			}
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	for i, r := range tbr {
+		if r == nil {
+			tbr[i] = &BatchResult{
+				Err: fmt.Errorf("No result seen for action %d [row: %q]", i, actions[i].row),
+			}
+		}
+	}
+
+	return tbr, nil
+}
+
+// batchError summarizes the failed items of a batch, nil if none failed.
+func batchError(results []*BatchResult) error {
+	failed := 0
+	first := -1
+
+	for i, r := range results {
+		if r.Err != nil {
+			failed++
+			if first == -1 {
+				first = i
+			}
+		}
+	}
+
+	if failed == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%d of %d actions failed, first at index %d: %v", failed, len(results), first, results[first].Err)
+}
+
+// batchRows returns the rows of results, nil for the failed items.
+func batchRows(results []*BatchResult) []*ResultRow {
+	rows := make([]*ResultRow, len(results))
+	for i, r := range results {
+		rows[i] = r.Row
+	}
+
+	return rows
+}
@@ -30,6 +30,9 @@ func newCall(request pb.Message) *call {
 	case *proto.ScanRequest:
 		responseBuffer = &proto.ScanResponse{}
 		methodName = "Scan"
+	case *proto.CoprocessorServiceRequest:
+		responseBuffer = &proto.CoprocessorServiceResponse{}
+		methodName = "ExecService"
 	case *proto.GetTableDescriptorsRequest:
 		responseBuffer = &proto.GetTableDescriptorsResponse{}
 		methodName = "GetTableDescriptors"
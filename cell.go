@@ -0,0 +1,106 @@
+package hbase
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/cugbliwei/go-hbase/proto"
+	pb "github.com/golang/protobuf/proto"
+)
+
+type CellType int32
+
+const (
+	CellPut          CellType = CellType(proto.CellType_PUT)
+	CellDelete       CellType = CellType(proto.CellType_DELETE)
+	CellDeleteColumn CellType = CellType(proto.CellType_DELETE_COLUMN)
+	CellDeleteFamily CellType = CellType(proto.CellType_DELETE_FAMILY)
+)
+
+func (t CellType) String() string {
+	return proto.CellType(t).String()
+}
+
+// Cell is one version of a column as stored by HBase. Delete cells are
+// only returned by raw scans.
+type Cell struct {
+	Row       EncodedValue
+	Family    EncodedValue
+	Qualifier EncodedValue
+	// milliseconds since the epoch
+	Timestamp int64
+	Type      CellType
+	Value     EncodedValue
+	// serialized cell tags, only sent to privileged clients
+	Tags []byte
+}
+
+func CellFromProto(c *proto.Cell) Cell {
+	return Cell{
+		Row:       c.GetRow(),
+		Family:    c.GetFamily(),
+		Qualifier: c.GetQualifier(),
+		Timestamp: int64(c.GetTimestamp()),
+		Type:      CellType(c.GetCellType()),
+		Value:     c.GetValue(),
+		Tags:      c.GetTags(),
+	}
+}
+
+func (c *Cell) ToProto() *proto.Cell {
+	p := &proto.Cell{
+		Row:       c.Row,
+		Family:    c.Family,
+		Qualifier: c.Qualifier,
+		Timestamp: pb.Uint64(uint64(c.Timestamp)),
+		CellType:  proto.CellType(c.Type).Enum(),
+		Value:     c.Value,
+	}
+
+	if c.Tags != nil {
+		p.Tags = c.Tags
+	}
+
+	return p
+}
+
+// Time is the timestamp of c as a time.
+func (c *Cell) Time() time.Time {
+	return msToTime(c.Timestamp)
+}
+
+// ColumnName is "family:qualifier".
+func (c *Cell) ColumnName() string {
+	return c.Family.String() + ":" + c.Qualifier.String()
+}
+
+// compareCells orders cells like HBase: by row, family and qualifier,
+// then newest first, and deletes before puts of the same timestamp.
+func compareCells(a, b *Cell) int {
+	if r := bytes.Compare(a.Row, b.Row); r != 0 {
+		return r
+	}
+	if r := bytes.Compare(a.Family, b.Family); r != 0 {
+		return r
+	}
+	if r := bytes.Compare(a.Qualifier, b.Qualifier); r != 0 {
+		return r
+	}
+
+	switch {
+	case a.Timestamp > b.Timestamp:
+		return -1
+	case a.Timestamp < b.Timestamp:
+		return 1
+	case a.Type > b.Type:
+		return -1
+	case a.Type < b.Type:
+		return 1
+	}
+
+	return 0
+}
+
+func msToTime(ms int64) time.Time {
+	return time.Unix(ms/1e3, (ms%1e3)*int64(time.Millisecond))
+}
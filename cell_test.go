@@ -0,0 +1,76 @@
+package hbase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cugbliwei/go-hbase/proto"
+	pb "github.com/golang/protobuf/proto"
+)
+
+func newTestCell(row, family, qualifier string, ts int64, typ CellType, value string) Cell {
+	return Cell{
+		Row:       EncodedValue(row),
+		Family:    EncodedValue(family),
+		Qualifier: EncodedValue(qualifier),
+		Timestamp: ts,
+		Type:      typ,
+		Value:     EncodedValue(value),
+	}
+}
+
+// newTestResultRow builds a row from cells sent in the given order.
+func newTestResultRow(cells ...Cell) *ResultRow {
+	result := &proto.Result{}
+	for i := range cells {
+		result.Cell = append(result.Cell, cells[i].ToProto())
+	}
+
+	return newResultRow(result)
+}
+
+func TestCompareCells(t *testing.T) {
+	// in HBase order
+	cells := []Cell{
+		newTestCell("a", "cf", "q", 1, CellPut, ""),
+		newTestCell("b", "cf", "q", 1, CellPut, ""),
+		newTestCell("b", "cg", "a", 1, CellPut, ""),
+		newTestCell("b", "cg", "b", 3, CellPut, ""),
+		newTestCell("b", "cg", "b", 2, CellDeleteColumn, ""),
+		newTestCell("b", "cg", "b", 2, CellDelete, ""),
+		newTestCell("b", "cg", "b", 2, CellPut, ""),
+		newTestCell("b", "cg", "b", 1, CellPut, ""),
+		newTestCell("b", "cg", "ba", 9, CellPut, ""),
+	}
+
+	for i := range cells {
+		for j := range cells {
+			got := compareCells(&cells[i], &cells[j])
+
+			want := 0
+			if i < j {
+				want = -1
+			} else if i > j {
+				want = 1
+			}
+
+			if got != want {
+				t.Errorf("compareCells(%d, %d) = %d, want %d", i, j, got, want)
+			}
+		}
+	}
+}
+
+func TestCellProtoRoundTrip(t *testing.T) {
+	c := newTestCell("row", "cf", "q", 1432648000123, CellDeleteFamily, "v")
+	c.Tags = []byte{1, 2}
+
+	got := CellFromProto(c.ToProto())
+	if !pb.Equal(got.ToProto(), c.ToProto()) {
+		t.Errorf("CellFromProto(ToProto()) = %+v, want %+v", got, c)
+	}
+
+	if want := time.Unix(1432648000, 123*int64(time.Millisecond)); !c.Time().Equal(want) {
+		t.Errorf("Time() = %v, want %v", c.Time(), want)
+	}
+}
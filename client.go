@@ -2,11 +2,14 @@ package hbase
 
 import (
 	"bytes"
+	"context"
 	"crypto/md5"
 	"encoding/binary"
 	"encoding/hex"
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/cugbliwei/dlog"
@@ -15,6 +18,9 @@ import (
 	"github.com/samuel/go-zookeeper/zk"
 )
 
+// Client is a connection to the HBase cluster found through ZooKeeper.
+// Its operations name tables as "namespace:table", or "table" in the
+// default namespace, see ParseTableName.
 type Client struct {
 	zkClient         *zk.Conn
 	zkHosts          []string
@@ -23,14 +29,22 @@ type Client struct {
 	user             string
 
 	servers               map[string]*connection
-	cachedRegionLocations map[string]map[string]*regionInfo
+	cachedRegionLocations map[TableName]map[string]*regionInfo
 
 	maxRetries int
+	// regions called at once by a coprocessor call over a range
+	coprocessorConcurrency int
 
-	prefetched map[string]bool
+	prefetched map[TableName]bool
 
 	rootServer   *proto.ServerName
 	masterServer *proto.ServerName
+
+	// guards servers, dialing, cachedRegionLocations and prefetched,
+	// which are shared by concurrent scans and batch actions
+	lock *sync.Mutex
+	// connections being dialed, by server
+	dialing map[string]*sync.WaitGroup
 }
 
 type silentLogger struct{}
@@ -48,10 +62,13 @@ func NewClient(zkHosts []string, zkRoot, user string) *Client {
 		zkRootRegionPath: "/meta-region-server",
 		user:             user,
 
-		servers:               make(map[string]*connection),
-		cachedRegionLocations: make(map[string]map[string]*regionInfo),
-		prefetched:            make(map[string]bool),
-		maxRetries:            max_action_retries,
+		servers:                make(map[string]*connection),
+		cachedRegionLocations:  make(map[TableName]map[string]*regionInfo),
+		prefetched:             make(map[TableName]bool),
+		maxRetries:             max_action_retries,
+		coprocessorConcurrency: coprocessor_concurrency,
+		lock:                   &sync.Mutex{},
+		dialing:                make(map[string]*sync.WaitGroup),
 	}
 
 	cl.initZk()
@@ -76,7 +93,9 @@ func (c *Client) initZk() {
 	}
 
 	c.rootServer = c.decodeMeta(res)
-	c.getRegionConnection(c.getServerName(c.rootServer))
+	if _, err := c.getRegionConnection(c.getServerName(c.rootServer)); err != nil {
+		dlog.Warn("meta region server connect error: %v", err)
+	}
 
 	res, _, _, err = c.zkClient.GetW(c.zkRoot + "/master")
 
@@ -113,53 +132,108 @@ func (c *Client) getServerName(server *proto.ServerName) string {
 	return fmt.Sprintf("%s:%d", server.GetHostName(), server.GetPort())
 }
 
-func (c *Client) getRegionConnection(server string) *connection {
-	if s, ok := c.servers[server]; ok {
-		return s
-	}
+func (c *Client) getRegionConnection(server string) (*connection, error) {
+	return c.getConnection(server, false)
+}
 
-	conn, err := newConnection(server, c.user, false)
-	if err != nil {
-		panic(err)
+func (c *Client) getMasterConnection() (*connection, error) {
+	return c.getConnection(c.getServerName(c.masterServer), true)
+}
+
+// getConnection returns the cached connection to server or dials a new
+// one. The lock isn't held while dialing so a slow server doesn't block
+// the others, concurrent callers for the same server wait for one dial.
+// A failed dial is returned to each caller, the next one dials again.
+func (c *Client) getConnection(server string, isMaster bool) (*connection, error) {
+	c.lock.Lock()
+	for {
+		if s, ok := c.servers[server]; ok {
+			c.lock.Unlock()
+			return s, nil
+		}
+
+		dial, ok := c.dialing[server]
+		if !ok {
+			break
+		}
+
+		c.lock.Unlock()
+		dial.Wait()
+		c.lock.Lock()
 	}
 
-	c.servers[server] = conn
+	dial := &sync.WaitGroup{}
+	dial.Add(1)
+	c.dialing[server] = dial
+	c.lock.Unlock()
 
-	return conn
-}
+	conn, err := newConnection(server, c.user, isMaster)
 
-func (c *Client) getMasterConnection() *connection {
-	server := c.getServerName(c.masterServer)
-	if s, ok := c.servers[server]; ok {
-		return s
+	c.lock.Lock()
+	delete(c.dialing, server)
+	if err == nil {
+		c.servers[server] = conn
 	}
+	c.lock.Unlock()
+	dial.Done()
 
-	conn, err := newConnection(server, c.user, true)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("Unable to connect [server: %s] [err: %v]", server, err)
 	}
 
-	c.servers[server] = conn
+	return conn, nil
+}
 
-	return conn
+// purgeServer drops a dead connection, the next call reconnects.
+func (c *Client) purgeServer(server string) {
+	c.lock.Lock()
+	delete(c.servers, server)
+	c.lock.Unlock()
 }
 
-func (c *Client) adminAction(req pb.Message) chan pb.Message {
-	conn := c.getMasterConnection()
+func (c *Client) adminAction(ctx context.Context, req pb.Message) pb.Message {
+	conn, err := c.getMasterConnection()
+	if err != nil {
+		return &exception{
+			msg: err.Error(),
+		}
+	}
+
 	cl := newCall(req)
 
-	err := conn.call(cl)
+	err = conn.call(cl)
 
 	if err != nil {
 		panic(err)
 	}
 
-	return cl.responseCh
+	return conn.wait(ctx, cl)
 }
 
-func (c *Client) action(table, row []byte, action action, useCache bool, retries int) chan pb.Message {
-	region := c.locateRegion(table, row, useCache)
-	conn := c.getRegionConnection(region.server)
+func (c *Client) action(ctx context.Context, table TableName, row []byte, action action, useCache bool, retries int) chan pb.Message {
+	result := make(chan pb.Message, 1)
+
+	region, err := c.locateRegion(ctx, table, row, useCache)
+	if err != nil {
+		result <- &exception{
+			msg: err.Error(),
+		}
+		return result
+	}
+
+	conn, err := c.getRegionConnection(region.server)
+	if err != nil {
+		// the server is gone, its regions are reassigned
+		if retries <= c.maxRetries && ctx.Err() == nil {
+			dlog.Info("connect error retrying action rowkey: %s for the %d time", string(row), retries+1)
+			return c.action(ctx, table, row, action, false, retries+1)
+		}
+
+		result <- &exception{
+			msg: err.Error(),
+		}
+		return result
+	}
 
 	regionSpecifier := &proto.RegionSpecifier{
 		Type:  proto.RegionSpecifier_REGION_NAME.Enum(),
@@ -173,24 +247,56 @@ func (c *Client) action(table, row []byte, action action, useCache bool, retries
 			Region: regionSpecifier,
 			Get:    a.toProto().(*proto.Get),
 		})
-	case *Put, *Delete:
+	case *Put, *Delete, *Increment, *Append:
 		cl = newCall(&proto.MutateRequest{
 			Region:   regionSpecifier,
 			Mutation: a.toProto().(*proto.MutationProto),
 		})
-	}
+	case *RowMutations:
+		ra := a.toProto().(*proto.RegionAction)
+		ra.Region = regionSpecifier
 
-	result := make(chan pb.Message)
+		cl = newCall(&proto.MultiRequest{
+			RegionAction: []*proto.RegionAction{ra},
+		})
+	case *checkAndMutate:
+		if rm, ok := a.mutation.(*RowMutations); ok {
+			ra := rm.toProto().(*proto.RegionAction)
+			ra.Region = regionSpecifier
+
+			cl = newCall(&proto.MultiRequest{
+				RegionAction: []*proto.RegionAction{ra},
+				Condition:    a.condition,
+			})
+			break
+		}
+
+		cl = newCall(&proto.MutateRequest{
+			Region:    regionSpecifier,
+			Mutation:  a.toProto().(*proto.MutationProto),
+			Condition: a.condition,
+		})
+	case *coprocessorCall:
+		call := a.toProto().(*proto.CoprocessorServiceCall)
+		if row != nil {
+			call.Row = row
+		}
+
+		cl = newCall(&proto.CoprocessorServiceRequest{
+			Region: regionSpecifier,
+			Call:   call,
+		})
+	}
 
 	go func() {
-		r := <-cl.responseCh
+		r := conn.wait(ctx, cl)
 
 		switch r.(type) {
 		case *exception:
-			if retries <= c.maxRetries {
+			if retries <= c.maxRetries && ctx.Err() == nil {
 				// retry action
 				dlog.Info("exception retrying action rowkey: %s for the %d time", string(row), retries+1)
-				newr := c.action(table, row, action, false, retries+1)
+				newr := c.action(ctx, table, row, action, false, retries+1)
 				result <- <-newr
 			} else {
 				result <- r
@@ -207,13 +313,9 @@ func (c *Client) action(table, row []byte, action action, useCache bool, retries
 		if err != nil {
 			dlog.Warn("Error return while attempting call [err=%#v]", err)
 			// purge dead server
-			delete(c.servers, region.server)
-
-			if retries <= c.maxRetries {
-				// retry action
-				dlog.Info("call warning retrying action rowkey: %s for the %d time", string(row), retries+1)
-				c.action(table, row, action, false, retries+1)
-			}
+			c.purgeServer(region.server)
+			// let the waiting goroutine retry
+			cl.complete(err, nil)
 		}
 	}
 
@@ -223,13 +325,22 @@ func (c *Client) action(table, row []byte, action action, useCache bool, retries
 type multiaction struct {
 	row    []byte
 	action action
+	// position in the caller's batch, echoed back in ResultOrException
+	index int
 }
 
-func (c *Client) multiaction(table []byte, actions []multiaction, useCache bool, retries int) chan pb.Message {
+func (c *Client) multiaction(ctx context.Context, table TableName, actions []multiaction, useCache bool, retries int) chan pb.Message {
 	actionsByServer := make(map[string]map[string][]multiaction)
+	unlocated := make([]multiaction, 0)
+	var locateErr error
 
 	for _, action := range actions {
-		region := c.locateRegion(table, action.row, useCache)
+		region, err := c.locateRegion(ctx, table, action.row, useCache)
+		if err != nil {
+			unlocated = append(unlocated, action)
+			locateErr = err
+			continue
+		}
 
 		if _, ok := actionsByServer[region.server]; !ok {
 			actionsByServer[region.server] = make(map[string][]multiaction)
@@ -244,6 +355,13 @@ func (c *Client) multiaction(table []byte, actions []multiaction, useCache bool,
 
 	chs := make([]chan pb.Message, 0)
 
+	if len(unlocated) > 0 {
+		result := make(chan pb.Message, 1)
+		result <- failedMultiResponse(unlocated, local_exception, locateErr.Error())
+		close(result)
+		chs = append(chs, result)
+	}
+
 	for server, as := range actionsByServer {
 		region_actions := make([]*proto.RegionAction, len(as))
 
@@ -252,13 +370,13 @@ func (c *Client) multiaction(table []byte, actions []multiaction, useCache bool,
 			racts := make([]*proto.Action, len(acts))
 			for j, act := range acts {
 				racts[j] = &proto.Action{
-					Index: pb.Uint32(uint32(j)),
+					Index: pb.Uint32(uint32(act.index)),
 				}
 
 				switch a := act.action.(type) {
 				case *Get:
 					racts[j].Get = a.toProto().(*proto.Get)
-				case *Put, *Delete:
+				case *Put, *Delete, *Increment, *Append:
 					racts[j].Mutation = a.toProto().(*proto.MutationProto)
 				}
 			}
@@ -280,37 +398,60 @@ func (c *Client) multiaction(table []byte, actions []multiaction, useCache bool,
 
 		cl := newCall(req)
 
+		// a request which could not be sent was not applied, all of its
+		// actions can be resubmitted
+		conn, sendErr := c.getRegionConnection(server)
+		if sendErr == nil {
+			if sendErr = conn.call(cl); sendErr != nil {
+				c.purgeServer(server)
+			}
+		}
+
 		result := make(chan pb.Message)
 
 		go func(actionsByServer map[string]map[string][]multiaction, server string) {
-			r := <-cl.responseCh
+			defer close(result)
 
-			switch r.(type) {
-			case *exception:
-				actions := make([]multiaction, 0)
-				for _, acts := range actionsByServer[server] {
-					actions = append(actions, acts...)
-				}
-				newr := c.multiaction(table, actions, false, retries+1)
+			actions := make([]multiaction, 0)
+			for _, acts := range actionsByServer[server] {
+				actions = append(actions, acts...)
+			}
 
-				for x := range newr {
-					result <- x
+			var res *proto.MultiResponse
+			if sendErr != nil {
+				res = failedMultiResponse(actions, call_not_sent_exception, sendErr.Error())
+			} else {
+				switch r := conn.wait(ctx, cl).(type) {
+				case *exception:
+					res = failedMultiResponse(actions, exceptionName(r.msg), r.msg)
+				case *proto.MultiResponse:
+					res = expandRegionExceptions(req, r)
+				default:
+					res = failedMultiResponse(actions, local_exception, fmt.Sprintf("Invalid response returned: %T", r))
 				}
+			}
+
+			if retries >= c.maxRetries || ctx.Err() != nil {
+				result <- res
 				return
-			default:
-				result <- r
 			}
 
-			close(result)
-		}(actionsByServer, server)
+			// only resubmit what failed because its region moved or was
+			// never sent, so mutations which were applied are not applied twice
+			failed := takeFailedActions(res, actions)
+			if len(failed) == 0 {
+				result <- res
+				return
+			}
 
-		conn := c.getRegionConnection(server)
-		err := conn.call(cl)
+			dlog.Info("retrying %d of %d actions on server %s for the %d time", len(failed), len(actions), server, retries+1)
 
-		if err != nil {
-			delete(c.servers, server)
-			cl.complete(err, nil)
-		}
+			result <- res
+
+			for x := range c.multiaction(ctx, table, failed, false, retries+1) {
+				result <- x
+			}
+		}(actionsByServer, server)
 
 		chs = append(chs, result)
 	}
@@ -318,7 +459,88 @@ func (c *Client) multiaction(table []byte, actions []multiaction, useCache bool,
 	return merge(chs...)
 }
 
-func (c *Client) locateRegion(table, row []byte, useCache bool) *regionInfo {
+// failedMultiResponse reports every action of acts as failed with the
+// exception name and msg, so batch callers know exactly which items failed.
+func failedMultiResponse(acts []multiaction, name, msg string) *proto.MultiResponse {
+	roes := make([]*proto.ResultOrException, len(acts))
+	for i, act := range acts {
+		roes[i] = &proto.ResultOrException{
+			Index: pb.Uint32(uint32(act.index)),
+			Exception: &proto.NameBytesPair{
+				Name:  pb.String(name),
+				Value: []byte(msg),
+			},
+		}
+	}
+
+	return &proto.MultiResponse{
+		RegionActionResult: []*proto.RegionActionResult{&proto.RegionActionResult{
+			ResultOrException: roes,
+		}},
+	}
+}
+
+// expandRegionExceptions copies the exception of a region which failed
+// as a whole to each of its actions. Region results come in the order
+// of the region actions of req.
+func expandRegionExceptions(req *proto.MultiRequest, res *proto.MultiResponse) *proto.MultiResponse {
+	for i, rar := range res.GetRegionActionResult() {
+		if rar.GetException() == nil || i >= len(req.GetRegionAction()) {
+			continue
+		}
+
+		rar.ResultOrException = make([]*proto.ResultOrException, 0)
+		for _, a := range req.GetRegionAction()[i].GetAction() {
+			rar.ResultOrException = append(rar.ResultOrException, &proto.ResultOrException{
+				Index:     a.Index,
+				Exception: rar.GetException(),
+			})
+		}
+	}
+
+	return res
+}
+
+// takeFailedActions removes the results carrying a retryable exception
+// from res and returns the actions they belong to. Other exceptions, such
+// as a missing column family, are kept as the results of their actions.
+func takeFailedActions(res *proto.MultiResponse, acts []multiaction) []multiaction {
+	byIndex := make(map[uint32]multiaction, len(acts))
+	for _, act := range acts {
+		byIndex[uint32(act.index)] = act
+	}
+
+	failed := make([]multiaction, 0)
+	for _, rar := range res.GetRegionActionResult() {
+		kept := make([]*proto.ResultOrException, 0, len(rar.GetResultOrException()))
+		for _, roe := range rar.GetResultOrException() {
+			act, ok := byIndex[roe.GetIndex()]
+			if e := roe.GetException(); e != nil && retryableException(e) && ok {
+				failed = append(failed, act)
+				continue
+			}
+
+			kept = append(kept, roe)
+		}
+
+		rar.ResultOrException = kept
+		rar.Exception = nil
+	}
+
+	return failed
+}
+
+// retryableException tells whether an action which failed with e may be
+// resubmitted once its region is located again: the region moved, or the
+// call could not be sent to its server, whose regions are then reassigned.
+// A call which was sent may have been applied, even if its response was lost.
+func retryableException(e *proto.NameBytesPair) bool {
+	return e.GetName() == call_not_sent_exception || regionMoved(e.GetName())
+}
+
+// locateRegion returns the region of table holding row, from the cache
+// unless useCache is false or from a lookup in meta.
+func (c *Client) locateRegion(ctx context.Context, table TableName, row []byte, useCache bool) (*regionInfo, error) {
 	metaRegion := &regionInfo{
 		startKey: []byte{},
 		endKey:   []byte{},
@@ -326,17 +548,20 @@ func (c *Client) locateRegion(table, row []byte, useCache bool) *regionInfo {
 		server:   c.getServerName(c.rootServer),
 	}
 
-	if bytes.Equal(table, meta_table_name) {
-		return metaRegion
+	if table == meta_table_name {
+		return metaRegion, nil
 	}
 
-	c.prefetchRegionCache(table)
+	c.prefetchRegionCache(ctx, table)
 
 	if r := c.getCachedLocation(table, row); r != nil && useCache {
-		return r
+		return r, nil
 	}
 
-	conn := c.getRegionConnection(metaRegion.server)
+	conn, err := c.getRegionConnection(metaRegion.server)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to lookup region [table: %s] [row: %q] [err: %v]", table, row, err)
+	}
 
 	regionRow := c.createRegionName(table, row, "", true)
 
@@ -354,28 +579,68 @@ func (c *Client) locateRegion(table, row []byte, useCache bool) *regionInfo {
 		},
 	})
 
-	conn.call(call)
+	if err := conn.call(call); err != nil {
+		dlog.Warn("Unable to lookup region [err=%#v]", err)
+		c.purgeServer(metaRegion.server)
+		return nil, fmt.Errorf("Unable to lookup region [table: %s] [row: %q] [err: %v]", table, row, err)
+	}
 
-	response := <-call.responseCh
+	response := conn.wait(ctx, call)
 
 	switch r := response.(type) {
 	case *proto.GetResponse:
+		// the closest meta row before a missing table is a region of
+		// another table, or none at all
 		rr := newResultRow(r.GetResult())
-		if region := c.parseRegion(rr); region != nil {
-			c.cacheLocation(table, region)
-			return region
+		if len(rr.Cells) == 0 {
+			return nil, fmt.Errorf("Table not found [table: %s]", table)
+		}
+
+		region := c.parseRegion(rr)
+		if region == nil {
+			return nil, fmt.Errorf("Unable to parse region location [table: %s] [row: %q]", table, row)
+		}
+		if region.table != table {
+			return nil, fmt.Errorf("Table not found [table: %s]", table)
+		}
+
+		c.cacheLocation(table, region)
+		return region, nil
+	case *exception:
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("Unable to lookup region [table: %s] [row: %q] [err: %s]", table, row, r.msg)
+	}
+
+	return nil, responseError(ctx, response)
+}
+
+// locateLastRegion returns the region holding the end of table, where
+// reversed scans without a start row begin.
+func (c *Client) locateLastRegion(ctx context.Context, table TableName) *regionInfo {
+	c.prefetchRegionCache(ctx, table)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if regions, ok := c.cachedRegionLocations[table]; ok {
+		for _, region := range regions {
+			if len(region.endKey) == 0 {
+				return region
+			}
 		}
 	}
 
 	return nil
 }
 
-func (c *Client) createRegionName(table, startKey []byte, id string, newFormat bool) []byte {
+func (c *Client) createRegionName(table TableName, startKey []byte, id string, newFormat bool) []byte {
 	if len(startKey) == 0 {
 		startKey = make([]byte, 1)
 	}
 
-	b := bytes.Join([][]byte{table, startKey, []byte(id)}, []byte(","))
+	b := bytes.Join([][]byte{[]byte(table.String()), startKey, []byte(id)}, []byte(","))
 
 	if newFormat {
 		m := md5.Sum(b)
@@ -385,31 +650,47 @@ func (c *Client) createRegionName(table, startKey []byte, id string, newFormat b
 	return b
 }
 
-func (c *Client) prefetchRegionCache(table []byte) {
-	if bytes.Equal(table, meta_table_name) {
-		return
+// prefetchRegionCache caches every region of table with one scan of meta.
+// Callers looking up a single row may ignore its error, they fall back
+// to a meta lookup of that row.
+func (c *Client) prefetchRegionCache(ctx context.Context, table TableName) error {
+	if table == meta_table_name {
+		return nil
 	}
 
-	if v, ok := c.prefetched[string(table)]; ok && v {
-		return
+	c.lock.Lock()
+	v, ok := c.prefetched[table]
+	c.lock.Unlock()
+
+	if ok && v {
+		return nil
 	}
 
-	startRow := table
-	stopRow := incrementByteString(table, len(table)-1)
+	// meta rows of table start with "table,", ',' + 1 is '-'
+	startRow := []byte(table.String() + ",")
+	stopRow := []byte(table.String() + "-")
 
-	scan := newScan(meta_table_name, c)
+	scan := newScan(ctx, meta_table_name, c)
 
 	scan.StartRow = startRow
 	scan.StopRow = stopRow
 
 	scan.Map(func(r *ResultRow) {
 		region := c.parseRegion(r)
-		if region != nil {
+		if region != nil && region.table == table {
 			c.cacheLocation(table, region)
 		}
 	})
 
-	c.prefetched[string(table)] = true
+	if err := scan.Err(); err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	c.prefetched[table] = true
+	c.lock.Unlock()
+
+	return nil
 }
 
 func (c *Client) parseRegion(rr *ResultRow) *regionInfo {
@@ -425,13 +706,12 @@ func (c *Client) parseRegion(rr *ResultRow) *regionInfo {
 		}
 
 		return &regionInfo{
-			server:         rr.Columns["info:server"].Value.String(),
-			startKey:       info.GetStartKey(),
-			endKey:         info.GetEndKey(),
-			name:           rr.Row.String(),
-			tableNamespace: string(info.GetTableName().GetNamespace()),
-			tableName:      string(info.GetTableName().GetQualifier()),
-			ts:             rr.Columns["info:server"].Timestamp.String(),
+			server:   rr.Columns["info:server"].Value.String(),
+			startKey: info.GetStartKey(),
+			endKey:   info.GetEndKey(),
+			name:     rr.Row.String(),
+			table:    tableNameFromProto(info.GetTableName()),
+			ts:       rr.Columns["info:server"].Timestamp.String(),
 		}
 	}
 
@@ -440,19 +720,32 @@ func (c *Client) parseRegion(rr *ResultRow) *regionInfo {
 	return nil
 }
 
-func (c *Client) cacheLocation(table []byte, region *regionInfo) {
-	tablestr := string(table)
-	if _, ok := c.cachedRegionLocations[tablestr]; !ok {
-		c.cachedRegionLocations[tablestr] = make(map[string]*regionInfo)
+func (c *Client) cacheLocation(table TableName, region *regionInfo) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if _, ok := c.cachedRegionLocations[table]; !ok {
+		c.cachedRegionLocations[table] = make(map[string]*regionInfo)
+	}
+
+	// a region which split or merged replaces the cached ones it overlaps
+	for name, cached := range c.cachedRegionLocations[table] {
+		if name != region.name &&
+			(len(cached.endKey) == 0 || bytes.Compare(region.startKey, cached.endKey) < 0) &&
+			(len(region.endKey) == 0 || bytes.Compare(cached.startKey, region.endKey) < 0) {
+
+			delete(c.cachedRegionLocations[table], name)
+		}
 	}
 
-	c.cachedRegionLocations[tablestr][region.name] = region
+	c.cachedRegionLocations[table][region.name] = region
 }
 
-func (c *Client) getCachedLocation(table, row []byte) *regionInfo {
-	tablestr := string(table)
+func (c *Client) getCachedLocation(table TableName, row []byte) *regionInfo {
+	c.lock.Lock()
+	defer c.lock.Unlock()
 
-	if regions, ok := c.cachedRegionLocations[tablestr]; ok {
+	if regions, ok := c.cachedRegionLocations[table]; ok {
 		for _, region := range regions {
 			if (len(region.endKey) == 0 ||
 				bytes.Compare(row, region.endKey) < 0) &&
@@ -466,3 +759,33 @@ func (c *Client) getCachedLocation(table, row []byte) *regionInfo {
 
 	return nil
 }
+
+// regionsInRange returns the cached regions of table overlapping
+// [startRow, stopRow), sorted by start key. Empty rows are unbounded.
+// It fails when the regions of table could not be read from meta.
+func (c *Client) regionsInRange(ctx context.Context, table TableName, startRow, stopRow []byte) ([]*regionInfo, error) {
+	if err := c.prefetchRegionCache(ctx, table); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("Unable to lookup regions [table: %s] [err: %v]", table, err)
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	regions := make([]*regionInfo, 0)
+	for _, region := range c.cachedRegionLocations[table] {
+		if (len(region.endKey) == 0 || bytes.Compare(startRow, region.endKey) < 0) &&
+			(len(stopRow) == 0 || bytes.Compare(region.startKey, stopRow) < 0) {
+
+			regions = append(regions, region)
+		}
+	}
+
+	sort.Slice(regions, func(i, j int) bool {
+		return bytes.Compare(regions[i].startKey, regions[j].startKey) < 0
+	})
+
+	return regions, nil
+}
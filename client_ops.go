@@ -1,13 +1,23 @@
 package hbase
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 
 	"github.com/cugbliwei/go-hbase/proto"
+	pb "github.com/golang/protobuf/proto"
 )
 
+// Every operation has a Context variant which gives up once ctx is done,
+// returning ctx.Err(). The plain variants never time out.
+
 func (c *Client) Get(table string, get *Get) (*ResultRow, error) {
-	ch := c.action([]byte(table), get.key, get, false, 0)
+	return c.GetContext(context.Background(), table, get)
+}
+
+func (c *Client) GetContext(ctx context.Context, table string, get *Get) (*ResultRow, error) {
+	ch := c.action(ctx, ParseTableName(table), get.key, get, false, 0)
 
 	response := <-ch
 	switch r := response.(type) {
@@ -15,20 +25,25 @@ func (c *Client) Get(table string, get *Get) (*ResultRow, error) {
 		return newResultRow(r.GetResult()), nil
 	}
 
-	return nil, fmt.Errorf("No valid response seen [response: %#v]", response)
+	return nil, responseError(ctx, response)
 }
 
 func (c *Client) AsyncGets(table string, results chan *ResultRow, gets []*Get) {
+	c.AsyncGetsContext(context.Background(), table, results, gets)
+}
+
+func (c *Client) AsyncGetsContext(ctx context.Context, table string, results chan *ResultRow, gets []*Get) {
 	actions := make([]multiaction, len(gets))
 
 	for i, v := range gets {
 		actions[i] = multiaction{
 			row:    v.key,
 			action: v,
+			index:  i,
 		}
 	}
 
-	ch := c.multiaction([]byte(table), actions, true, 0)
+	ch := c.multiaction(ctx, ParseTableName(table), actions, true, 0)
 
 	for r := range ch {
 		switch rs := r.(type) {
@@ -47,19 +62,107 @@ func (c *Client) AsyncGets(table string, results chan *ResultRow, gets []*Get) {
 }
 
 func (c *Client) Gets(table string, gets []*Get) ([]*ResultRow, error) {
-	results := make(chan *ResultRow, 100)
-	go c.AsyncGets(table, results, gets)
-	tbr := make([]*ResultRow, 0)
+	return c.GetsContext(context.Background(), table, gets)
+}
 
-	for r := range results {
-		tbr = append(tbr, r)
+// GetsContext returns the rows of gets in their order. When some gets
+// failed their rows are nil and the error tells which, use BatchGets for
+// every item's error.
+func (c *Client) GetsContext(ctx context.Context, table string, gets []*Get) ([]*ResultRow, error) {
+	results, err := c.BatchGetsContext(ctx, table, gets)
+	if err != nil {
+		return nil, err
+	}
+
+	return batchRows(results), batchError(results)
+}
+
+// Exists tells whether the row of get exists, without transferring it.
+func (c *Client) Exists(table string, get *Get) (bool, error) {
+	return c.ExistsContext(context.Background(), table, get)
+}
+
+func (c *Client) ExistsContext(ctx context.Context, table string, get *Get) (bool, error) {
+	g := *get
+	g.existenceOnly = true
+
+	ch := c.action(ctx, ParseTableName(table), g.key, &g, false, 0)
+
+	response := <-ch
+	switch r := response.(type) {
+	case *proto.GetResponse:
+		return r.GetResult().GetExists(), nil
+	}
+
+	return false, responseError(ctx, response)
+}
+
+// ExistsAll tells whether the row of each get exists, in the order of gets.
+func (c *Client) ExistsAll(table string, gets []*Get) ([]bool, error) {
+	return c.ExistsAllContext(context.Background(), table, gets)
+}
+
+func (c *Client) ExistsAllContext(ctx context.Context, table string, gets []*Get) ([]bool, error) {
+	actions := make([]multiaction, len(gets))
+
+	for i, v := range gets {
+		g := *v
+		g.existenceOnly = true
+
+		actions[i] = multiaction{
+			row:    g.key,
+			action: &g,
+			index:  i,
+		}
+	}
+
+	ch := c.multiaction(ctx, ParseTableName(table), actions, true, 0)
+
+	tbr := make([]bool, len(gets))
+	seen := make([]bool, len(gets))
+
+	for r := range ch {
+		switch rs := r.(type) {
+		case *proto.MultiResponse:
+			for _, v := range rs.GetRegionActionResult() {
+				for _, v2 := range v.GetResultOrException() {
+					i := int(v2.GetIndex())
+					if i >= len(gets) {
+						continue
+					}
+
+					if e := v2.GetException(); e != nil {
+						return nil, fmt.Errorf("Exists failed for get %d [row: %q] [exception: %s] [msg: %s]", i, gets[i].key, e.GetName(), e.GetValue())
+					}
+
+					if res := v2.GetResult(); res != nil {
+						tbr[i] = res.GetExists()
+						seen[i] = true
+					}
+				}
+			}
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	for i, ok := range seen {
+		if !ok {
+			return nil, fmt.Errorf("No existence result seen for get %d [row: %q]", i, gets[i].key)
+		}
 	}
 
 	return tbr, nil
 }
 
 func (c *Client) Put(table string, put *Put) (bool, error) {
-	ch := c.action([]byte(table), put.key, put, true, 0)
+	return c.PutContext(context.Background(), table, put)
+}
+
+func (c *Client) PutContext(ctx context.Context, table string, put *Put) (bool, error) {
+	ch := c.action(ctx, ParseTableName(table), put.key, put, true, 0)
 
 	response := <-ch
 	switch r := response.(type) {
@@ -67,70 +170,277 @@ func (c *Client) Put(table string, put *Put) (bool, error) {
 		return r.GetProcessed(), nil
 	}
 
-	return false, fmt.Errorf("No valid response seen [response: %#v]", response)
+	return false, responseError(ctx, response)
 }
 
 func (c *Client) Puts(table string, puts []*Put) (bool, error) {
-	actions := make([]multiaction, len(puts))
+	return c.PutsContext(context.Background(), table, puts)
+}
+
+func (c *Client) PutsContext(ctx context.Context, table string, puts []*Put) (bool, error) {
+	results, err := c.BatchPutsContext(ctx, table, puts)
+	if err != nil {
+		return false, err
+	}
+
+	err = batchError(results)
+
+	return err == nil, err
+}
+
+func (c *Client) Delete(table string, del *Delete) (bool, error) {
+	return c.DeleteContext(context.Background(), table, del)
+}
+
+func (c *Client) DeleteContext(ctx context.Context, table string, del *Delete) (bool, error) {
+	ch := c.action(ctx, ParseTableName(table), del.key, del, true, 0)
+
+	response := <-ch
+	switch r := response.(type) {
+	case *proto.MutateResponse:
+		return r.GetProcessed(), nil
+	}
+
+	return false, responseError(ctx, response)
+}
+
+func (c *Client) Deletes(table string, dels []*Delete) (bool, error) {
+	return c.DeletesContext(context.Background(), table, dels)
+}
+
+func (c *Client) DeletesContext(ctx context.Context, table string, dels []*Delete) (bool, error) {
+	results, err := c.BatchDeletesContext(ctx, table, dels)
+	if err != nil {
+		return false, err
+	}
+
+	err = batchError(results)
 
-	for i, v := range puts {
+	return err == nil, err
+}
+
+// CheckAndPut applies put only if the value of row/family:qualifier
+// compares to expected with op. A nil expected value requires the cell
+// to not exist. The returned bool reports whether put was applied.
+func (c *Client) CheckAndPut(table string, row, family, qualifier []byte, op CompareOp, expected []byte, put *Put) (bool, error) {
+	return c.CheckAndPutContext(context.Background(), table, row, family, qualifier, op, expected, put)
+}
+
+func (c *Client) CheckAndPutContext(ctx context.Context, table string, row, family, qualifier []byte, op CompareOp, expected []byte, put *Put) (bool, error) {
+	return c.CheckAndPutComparatorContext(ctx, table, row, family, qualifier, op, NewBinaryComparator(expected), put)
+}
+
+// CheckAndPutComparator is CheckAndPut with an arbitrary comparator, a
+// nil cmp requires the cell to not exist.
+func (c *Client) CheckAndPutComparator(table string, row, family, qualifier []byte, op CompareOp, cmp Comparator, put *Put) (bool, error) {
+	return c.CheckAndPutComparatorContext(context.Background(), table, row, family, qualifier, op, cmp, put)
+}
+
+func (c *Client) CheckAndPutComparatorContext(ctx context.Context, table string, row, family, qualifier []byte, op CompareOp, cmp Comparator, put *Put) (bool, error) {
+	if !bytes.Equal(row, put.key) {
+		return false, fmt.Errorf("Put row must match the checked row [put: %s] [row: %s]", put.key, row)
+	}
+
+	return c.checkAndMutate(ctx, table, row, &checkAndMutate{
+		mutation:  put,
+		condition: newCondition(row, family, qualifier, op, cmp),
+	})
+}
+
+// CheckAndDelete applies del only if the value of row/family:qualifier
+// compares to expected with op. A nil expected value requires the cell
+// to not exist. The returned bool reports whether del was applied.
+func (c *Client) CheckAndDelete(table string, row, family, qualifier []byte, op CompareOp, expected []byte, del *Delete) (bool, error) {
+	return c.CheckAndDeleteContext(context.Background(), table, row, family, qualifier, op, expected, del)
+}
+
+func (c *Client) CheckAndDeleteContext(ctx context.Context, table string, row, family, qualifier []byte, op CompareOp, expected []byte, del *Delete) (bool, error) {
+	return c.CheckAndDeleteComparatorContext(ctx, table, row, family, qualifier, op, NewBinaryComparator(expected), del)
+}
+
+// CheckAndDeleteComparator is CheckAndDelete with an arbitrary comparator,
+// a nil cmp requires the cell to not exist.
+func (c *Client) CheckAndDeleteComparator(table string, row, family, qualifier []byte, op CompareOp, cmp Comparator, del *Delete) (bool, error) {
+	return c.CheckAndDeleteComparatorContext(context.Background(), table, row, family, qualifier, op, cmp, del)
+}
+
+func (c *Client) CheckAndDeleteComparatorContext(ctx context.Context, table string, row, family, qualifier []byte, op CompareOp, cmp Comparator, del *Delete) (bool, error) {
+	if !bytes.Equal(row, del.key) {
+		return false, fmt.Errorf("Delete row must match the checked row [delete: %s] [row: %s]", del.key, row)
+	}
+
+	return c.checkAndMutate(ctx, table, row, &checkAndMutate{
+		mutation:  del,
+		condition: newCondition(row, family, qualifier, op, cmp),
+	})
+}
+
+// MutateRow applies the puts and deletes of rm atomically.
+func (c *Client) MutateRow(table string, rm *RowMutations) error {
+	return c.MutateRowContext(context.Background(), table, rm)
+}
+
+func (c *Client) MutateRowContext(ctx context.Context, table string, rm *RowMutations) error {
+	ch := c.action(ctx, ParseTableName(table), rm.key, rm, true, 0)
+
+	response := <-ch
+	switch r := response.(type) {
+	case *proto.MultiResponse:
+		_, err := rowMutationsResult(r)
+		return err
+	}
+
+	return responseError(ctx, response)
+}
+
+// CheckAndMutateRow applies rm atomically only if the value of
+// row/family:qualifier compares to expected with op, like CheckAndPut.
+func (c *Client) CheckAndMutateRow(table string, row, family, qualifier []byte, op CompareOp, expected []byte, rm *RowMutations) (bool, error) {
+	return c.CheckAndMutateRowContext(context.Background(), table, row, family, qualifier, op, expected, rm)
+}
+
+func (c *Client) CheckAndMutateRowContext(ctx context.Context, table string, row, family, qualifier []byte, op CompareOp, expected []byte, rm *RowMutations) (bool, error) {
+	return c.CheckAndMutateRowComparatorContext(ctx, table, row, family, qualifier, op, NewBinaryComparator(expected), rm)
+}
+
+// CheckAndMutateRowComparator is CheckAndMutateRow with an arbitrary
+// comparator, a nil cmp requires the cell to not exist.
+func (c *Client) CheckAndMutateRowComparator(table string, row, family, qualifier []byte, op CompareOp, cmp Comparator, rm *RowMutations) (bool, error) {
+	return c.CheckAndMutateRowComparatorContext(context.Background(), table, row, family, qualifier, op, cmp, rm)
+}
+
+func (c *Client) CheckAndMutateRowComparatorContext(ctx context.Context, table string, row, family, qualifier []byte, op CompareOp, cmp Comparator, rm *RowMutations) (bool, error) {
+	if !bytes.Equal(row, rm.key) {
+		return false, fmt.Errorf("RowMutations row must match the checked row [mutations: %s] [row: %s]", rm.key, row)
+	}
+
+	return c.checkAndMutate(ctx, table, row, &checkAndMutate{
+		mutation:  rm,
+		condition: newCondition(row, family, qualifier, op, cmp),
+	})
+}
+
+func (c *Client) checkAndMutate(ctx context.Context, table string, row []byte, cam *checkAndMutate) (bool, error) {
+	ch := c.action(ctx, ParseTableName(table), row, cam, true, 0)
+
+	response := <-ch
+	switch r := response.(type) {
+	case *proto.MutateResponse:
+		return r.GetProcessed(), nil
+	case *proto.MultiResponse:
+		return rowMutationsResult(r)
+	case *exception:
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+		return false, fmt.Errorf("Check and mutate failed [err: %s]", r.msg)
+	}
+
+	return false, responseError(ctx, response)
+}
+
+func (c *Client) Increment(table string, inc *Increment) (*ResultRow, error) {
+	return c.IncrementContext(context.Background(), table, inc)
+}
+
+func (c *Client) IncrementContext(ctx context.Context, table string, inc *Increment) (*ResultRow, error) {
+	ch := c.action(ctx, ParseTableName(table), inc.key, inc, true, 0)
+
+	response := <-ch
+	switch r := response.(type) {
+	case *proto.MutateResponse:
+		return newResultRow(r.GetResult()), nil
+	}
+
+	return nil, responseError(ctx, response)
+}
+
+func (c *Client) Increments(table string, incs []*Increment) ([]*ResultRow, error) {
+	return c.IncrementsContext(context.Background(), table, incs)
+}
+
+func (c *Client) IncrementsContext(ctx context.Context, table string, incs []*Increment) ([]*ResultRow, error) {
+	actions := make([]multiaction, len(incs))
+
+	for i, v := range incs {
 		actions[i] = multiaction{
 			row:    v.key,
 			action: v,
+			index:  i,
 		}
 	}
 
-	ch := c.multiaction([]byte(table), actions, true, 0)
-
-	for _ = range ch {
+	results, err := c.batch(ctx, table, actions)
+	if err != nil {
+		return nil, err
 	}
 
-	return true, nil
+	return batchRows(results), batchError(results)
 }
 
-func (c *Client) Delete(table string, del *Delete) (bool, error) {
-	ch := c.action([]byte(table), del.key, del, true, 0)
+func (c *Client) Append(table string, app *Append) (*ResultRow, error) {
+	return c.AppendContext(context.Background(), table, app)
+}
+
+func (c *Client) AppendContext(ctx context.Context, table string, app *Append) (*ResultRow, error) {
+	ch := c.action(ctx, ParseTableName(table), app.key, app, true, 0)
 
 	response := <-ch
 	switch r := response.(type) {
 	case *proto.MutateResponse:
-		return r.GetProcessed(), nil
+		return newResultRow(r.GetResult()), nil
 	}
 
-	return false, fmt.Errorf("No valid response seen [response: %#v]", response)
+	return nil, responseError(ctx, response)
 }
 
-func (c *Client) Deletes(table string, dels []*Delete) (bool, error) {
-	actions := make([]multiaction, len(dels))
+func (c *Client) Appends(table string, apps []*Append) ([]*ResultRow, error) {
+	return c.AppendsContext(context.Background(), table, apps)
+}
 
-	for i, v := range dels {
+func (c *Client) AppendsContext(ctx context.Context, table string, apps []*Append) ([]*ResultRow, error) {
+	actions := make([]multiaction, len(apps))
+
+	for i, v := range apps {
 		actions[i] = multiaction{
 			row:    v.key,
 			action: v,
+			index:  i,
 		}
 	}
 
-	ch := c.multiaction([]byte(table), actions, true, 0)
-
-	for _ = range ch {
+	results, err := c.batch(ctx, table, actions)
+	if err != nil {
+		return nil, err
 	}
 
-	return true, nil
+	return batchRows(results), batchError(results)
 }
 
 func (c *Client) Scan(table string) *Scan {
-	return newScan([]byte(table), c)
+	return c.ScanContext(context.Background(), table)
+}
+
+// ScanContext returns a scan whose RPCs are bound to ctx, Next returns
+// ctx.Err() once it is done.
+func (c *Client) ScanContext(ctx context.Context, table string) *Scan {
+	return newScan(ctx, ParseTableName(table), c)
 }
 
 func (c *Client) GetTables() []TableInfo {
-	res := c.adminAction(&proto.GetTableDescriptorsRequest{})
+	tables, _ := c.GetTablesContext(context.Background())
+	return tables
+}
+
+func (c *Client) GetTablesContext(ctx context.Context) ([]TableInfo, error) {
+	response := c.adminAction(ctx, &proto.GetTableDescriptorsRequest{})
 
-	response := <-res
 	switch r := response.(type) {
 	case *proto.GetTableDescriptorsResponse:
 		tables := make([]TableInfo, len(r.GetTableSchema()))
 		for i, table := range r.GetTableSchema() {
 			tables[i] = TableInfo{
+				Namespace: string(table.GetTableName().GetNamespace()),
 				TableName: string(table.GetTableName().GetQualifier()),
 				Families:  make([]string, len(table.GetColumnFamilies())),
 			}
@@ -139,8 +449,18 @@ func (c *Client) GetTables() []TableInfo {
 				tables[i].Families[j] = string(cf.GetName())
 			}
 		}
-		return tables
+		return tables, nil
+	}
+
+	return nil, responseError(ctx, response)
+}
+
+// responseError reports ctx.Err() when the call was cancelled or timed
+// out, and the unexpected response otherwise.
+func responseError(ctx context.Context, response pb.Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
-	return nil
+	return fmt.Errorf("No valid response seen [response: %#v]", response)
 }
@@ -0,0 +1,156 @@
+package hbase
+
+import (
+	"testing"
+
+	"github.com/cugbliwei/go-hbase/proto"
+	pb "github.com/golang/protobuf/proto"
+)
+
+func testActions(n int) []multiaction {
+	acts := make([]multiaction, n)
+	for i := range acts {
+		acts[i] = multiaction{
+			row:    []byte{byte('a' + i)},
+			action: CreateNewGet([]byte{byte('a' + i)}),
+			index:  i,
+		}
+	}
+
+	return acts
+}
+
+func resultAt(index int) *proto.ResultOrException {
+	return &proto.ResultOrException{
+		Index:  pb.Uint32(uint32(index)),
+		Result: &proto.Result{},
+	}
+}
+
+func exceptionAt(index int, name string) *proto.ResultOrException {
+	return &proto.ResultOrException{
+		Index: pb.Uint32(uint32(index)),
+		Exception: &proto.NameBytesPair{
+			Name:  pb.String(name),
+			Value: []byte("failed"),
+		},
+	}
+}
+
+func TestTakeFailedActions(t *testing.T) {
+	acts := testActions(5)
+
+	res := &proto.MultiResponse{
+		RegionActionResult: []*proto.RegionActionResult{
+			&proto.RegionActionResult{
+				ResultOrException: []*proto.ResultOrException{
+					resultAt(0),
+					exceptionAt(1, "org.apache.hadoop.hbase.NotServingRegionException"),
+					exceptionAt(2, "org.apache.hadoop.hbase.regionserver.NoSuchColumnFamilyException"),
+				},
+			},
+			&proto.RegionActionResult{
+				ResultOrException: []*proto.ResultOrException{
+					exceptionAt(3, call_not_sent_exception),
+					exceptionAt(4, "org.apache.hadoop.hbase.exceptions.RegionMovedException"),
+				},
+			},
+		},
+	}
+
+	failed := takeFailedActions(res, acts)
+
+	if len(failed) != 3 || failed[0].index != 1 || failed[1].index != 3 || failed[2].index != 4 {
+		t.Fatalf("takeFailedActions returned %v, want actions 1, 3 and 4", failed)
+	}
+
+	// the results and the exceptions which are not retried stay
+	kept := make(map[uint32]bool)
+	for _, rar := range res.GetRegionActionResult() {
+		for _, roe := range rar.GetResultOrException() {
+			kept[roe.GetIndex()] = true
+		}
+	}
+
+	if len(kept) != 2 || !kept[0] || !kept[2] {
+		t.Errorf("takeFailedActions kept %v, want 0 and 2", kept)
+	}
+}
+
+func TestTakeFailedActionsNoneRetried(t *testing.T) {
+	acts := testActions(3)
+
+	res := &proto.MultiResponse{
+		RegionActionResult: []*proto.RegionActionResult{
+			&proto.RegionActionResult{
+				ResultOrException: []*proto.ResultOrException{
+					resultAt(0),
+					exceptionAt(1, "org.apache.hadoop.hbase.DoNotRetryIOException"),
+					// the response was lost, the batch may have been applied
+					exceptionAt(2, local_exception),
+				},
+			},
+		},
+	}
+
+	if failed := takeFailedActions(res, acts); len(failed) != 0 {
+		t.Errorf("takeFailedActions returned %v, want none", failed)
+	}
+
+	if n := len(res.GetRegionActionResult()[0].GetResultOrException()); n != 3 {
+		t.Errorf("takeFailedActions left %d results, want 3", n)
+	}
+}
+
+func TestExpandRegionExceptions(t *testing.T) {
+	req := &proto.MultiRequest{
+		RegionAction: []*proto.RegionAction{
+			&proto.RegionAction{
+				Action: []*proto.Action{
+					&proto.Action{Index: pb.Uint32(0)},
+				},
+			},
+			&proto.RegionAction{
+				Action: []*proto.Action{
+					&proto.Action{Index: pb.Uint32(1)},
+					&proto.Action{Index: pb.Uint32(2)},
+				},
+			},
+		},
+	}
+
+	moved := &proto.NameBytesPair{Name: pb.String("org.apache.hadoop.hbase.NotServingRegionException")}
+	res := &proto.MultiResponse{
+		RegionActionResult: []*proto.RegionActionResult{
+			&proto.RegionActionResult{
+				ResultOrException: []*proto.ResultOrException{resultAt(0)},
+			},
+			&proto.RegionActionResult{
+				Exception: moved,
+			},
+		},
+	}
+
+	failed := takeFailedActions(expandRegionExceptions(req, res), testActions(3))
+	if len(failed) != 2 || failed[0].index != 1 || failed[1].index != 2 {
+		t.Errorf("actions of the failed region = %v, want 1 and 2", failed)
+	}
+}
+
+func TestExceptionName(t *testing.T) {
+	tests := []struct {
+		msg  string
+		name string
+	}{
+		{server_exception_prefix + "org.apache.hadoop.hbase.NotServingRegionException\nstack", "org.apache.hadoop.hbase.NotServingRegionException"},
+		{server_exception_prefix + "java.io.IOException", "java.io.IOException"},
+		{"proto: cannot parse invalid wire-format data", local_exception},
+		{"context canceled", local_exception},
+	}
+
+	for _, tt := range tests {
+		if name := exceptionName(tt.msg); name != tt.name {
+			t.Errorf("exceptionName(%q) = %q, want %q", tt.msg, name, tt.name)
+		}
+	}
+}
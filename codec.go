@@ -0,0 +1,231 @@
+package hbase
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Encoders and decoders of the values written by Java's
+// org.apache.hadoop.hbase.util.Bytes. Numbers are big-endian, floats are
+// their IEEE 754 bits and booleans a single byte, 0xff for true.
+
+func EncodeInt16(v int16) []byte {
+	b := make([]byte, 2)
+	byte_order.PutUint16(b, uint16(v))
+	return b
+}
+
+func EncodeInt32(v int32) []byte {
+	b := make([]byte, 4)
+	byte_order.PutUint32(b, uint32(v))
+	return b
+}
+
+func EncodeInt64(v int64) []byte {
+	b := make([]byte, 8)
+	byte_order.PutUint64(b, uint64(v))
+	return b
+}
+
+func EncodeFloat32(v float32) []byte {
+	return EncodeInt32(int32(math.Float32bits(v)))
+}
+
+func EncodeFloat64(v float64) []byte {
+	return EncodeInt64(int64(math.Float64bits(v)))
+}
+
+func EncodeBool(v bool) []byte {
+	if v {
+		return []byte{0xff}
+	}
+	return []byte{0}
+}
+
+func DecodeInt16(b []byte) (int16, error) {
+	if len(b) != 2 {
+		return 0, fmt.Errorf("Invalid length for int16 value [len=%d]", len(b))
+	}
+
+	return int16(byte_order.Uint16(b)), nil
+}
+
+func DecodeInt32(b []byte) (int32, error) {
+	if len(b) != 4 {
+		return 0, fmt.Errorf("Invalid length for int32 value [len=%d]", len(b))
+	}
+
+	return int32(byte_order.Uint32(b)), nil
+}
+
+func DecodeInt64(b []byte) (int64, error) {
+	if len(b) != 8 {
+		return 0, fmt.Errorf("Invalid length for int64 value [len=%d]", len(b))
+	}
+
+	return int64(byte_order.Uint64(b)), nil
+}
+
+func DecodeFloat32(b []byte) (float32, error) {
+	if len(b) != 4 {
+		return 0, fmt.Errorf("Invalid length for float32 value [len=%d]", len(b))
+	}
+
+	return math.Float32frombits(byte_order.Uint32(b)), nil
+}
+
+func DecodeFloat64(b []byte) (float64, error) {
+	if len(b) != 8 {
+		return 0, fmt.Errorf("Invalid length for float64 value [len=%d]", len(b))
+	}
+
+	return math.Float64frombits(byte_order.Uint64(b)), nil
+}
+
+// DecodeBool is true for any non zero byte, like Java's Bytes.toBoolean.
+func DecodeBool(b []byte) (bool, error) {
+	if len(b) != 1 {
+		return false, fmt.Errorf("Invalid length for bool value [len=%d]", len(b))
+	}
+
+	return b[0] != 0, nil
+}
+
+// BigDecimal is Java's BigDecimal: Unscaled * 10^-Scale.
+type BigDecimal struct {
+	Unscaled *big.Int
+	Scale    int32
+}
+
+func NewBigDecimal(unscaled *big.Int, scale int32) *BigDecimal {
+	return &BigDecimal{
+		Unscaled: unscaled,
+		Scale:    scale,
+	}
+}
+
+// ParseBigDecimal parses a decimal like Java's BigDecimal(String), the
+// scale is kept: "1.50" has scale 2 and "1E+3" has scale -3.
+func ParseBigDecimal(s string) (*BigDecimal, error) {
+	mantissa, exp := s, int64(0)
+	if i := strings.IndexAny(s, "eE"); i != -1 {
+		mantissa = s[:i]
+
+		var err error
+		if exp, err = strconv.ParseInt(s[i+1:], 10, 32); err != nil {
+			return nil, fmt.Errorf("Invalid BigDecimal: %q", s)
+		}
+	}
+
+	digits := mantissa
+	frac := ""
+	if i := strings.IndexByte(mantissa, '.'); i != -1 {
+		digits = mantissa[:i]
+		frac = mantissa[i+1:]
+	}
+
+	if strings.ContainsAny(frac, "+-") || digits+frac == "" || digits+frac == "-" || digits+frac == "+" {
+		return nil, fmt.Errorf("Invalid BigDecimal: %q", s)
+	}
+
+	unscaled, ok := new(big.Int).SetString(digits+frac, 10)
+	if !ok {
+		return nil, fmt.Errorf("Invalid BigDecimal: %q", s)
+	}
+
+	scale := int64(len(frac)) - exp
+	if scale > math.MaxInt32 || scale < math.MinInt32 {
+		return nil, fmt.Errorf("BigDecimal scale out of range: %q", s)
+	}
+
+	return NewBigDecimal(unscaled, int32(scale)), nil
+}
+
+// Rat returns the exact value of d.
+func (d *BigDecimal) Rat() *big.Rat {
+	r := new(big.Rat).SetInt(d.Unscaled)
+
+	exp := int64(d.Scale)
+	if exp < 0 {
+		exp = -exp
+	}
+	pow := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(exp), nil))
+
+	if d.Scale < 0 {
+		return r.Mul(r, pow)
+	}
+
+	return r.Quo(r, pow)
+}
+
+// Float64 returns the nearest float64 to d.
+func (d *BigDecimal) Float64() float64 {
+	f, _ := d.Rat().Float64()
+	return f
+}
+
+// String formats d without exponent, like Java's toPlainString.
+func (d *BigDecimal) String() string {
+	s := new(big.Int).Abs(d.Unscaled).String()
+
+	if d.Scale <= 0 {
+		if d.Unscaled.Sign() != 0 {
+			s += strings.Repeat("0", int(-d.Scale))
+		}
+	} else {
+		if len(s) <= int(d.Scale) {
+			s = strings.Repeat("0", int(d.Scale)-len(s)+1) + s
+		}
+		s = s[:len(s)-int(d.Scale)] + "." + s[len(s)-int(d.Scale):]
+	}
+
+	if d.Unscaled.Sign() < 0 {
+		s = "-" + s
+	}
+
+	return s
+}
+
+// EncodeBigDecimal writes d like Java's Bytes.toBytes(BigDecimal): the
+// 4 byte scale followed by the minimal two's complement unscaled value.
+func EncodeBigDecimal(d *BigDecimal) []byte {
+	return append(EncodeInt32(d.Scale), twosComplement(d.Unscaled)...)
+}
+
+func DecodeBigDecimal(b []byte) (*BigDecimal, error) {
+	if len(b) < 5 {
+		return nil, fmt.Errorf("Invalid length for BigDecimal value [len=%d]", len(b))
+	}
+
+	unscaled := new(big.Int).SetBytes(b[4:])
+	if b[4]&0x80 != 0 {
+		unscaled.Sub(unscaled, new(big.Int).Lsh(big.NewInt(1), uint(8*len(b[4:]))))
+	}
+
+	return NewBigDecimal(unscaled, int32(byte_order.Uint32(b))), nil
+}
+
+// twosComplement is Java's BigInteger.toByteArray.
+func twosComplement(v *big.Int) []byte {
+	if v.Sign() >= 0 {
+		b := v.Bytes()
+		if len(b) == 0 || b[0]&0x80 != 0 {
+			b = append([]byte{0}, b...)
+		}
+		return b
+	}
+
+	// -v-1 has the inverted bits of v
+	b := new(big.Int).Sub(new(big.Int).Neg(v), big.NewInt(1)).Bytes()
+	for i := range b {
+		b[i] = ^b[i]
+	}
+	if len(b) == 0 || b[0]&0x80 == 0 {
+		b = append([]byte{0xff}, b...)
+	}
+
+	return b
+}
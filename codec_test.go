@@ -0,0 +1,285 @@
+package hbase
+
+import (
+	"bytes"
+	"math"
+	"math/big"
+	"testing"
+)
+
+// The expected bytes are those of Java's Bytes.toBytes, which writes
+// numbers big-endian, floats as their raw IEEE 754 bits, booleans as
+// 0xff/0x00 and BigDecimals as the scale followed by
+// BigInteger.toByteArray of the unscaled value.
+
+func TestInt16Codec(t *testing.T) {
+	tests := []struct {
+		v int16
+		b []byte
+	}{
+		{0, []byte{0x00, 0x00}},
+		{1, []byte{0x00, 0x01}},
+		{-1, []byte{0xff, 0xff}},
+		{-2, []byte{0xff, 0xfe}},
+		{0x1234, []byte{0x12, 0x34}},
+		{math.MaxInt16, []byte{0x7f, 0xff}},
+		{math.MinInt16, []byte{0x80, 0x00}},
+	}
+
+	for _, tt := range tests {
+		if b := EncodeInt16(tt.v); !bytes.Equal(b, tt.b) {
+			t.Errorf("EncodeInt16(%d) = %x, want %x", tt.v, b, tt.b)
+		}
+
+		v, err := DecodeInt16(tt.b)
+		if err != nil || v != tt.v {
+			t.Errorf("DecodeInt16(%x) = %d, %v, want %d", tt.b, v, err, tt.v)
+		}
+	}
+}
+
+func TestInt32Codec(t *testing.T) {
+	tests := []struct {
+		v int32
+		b []byte
+	}{
+		{0, []byte{0x00, 0x00, 0x00, 0x00}},
+		{1, []byte{0x00, 0x00, 0x00, 0x01}},
+		{-1, []byte{0xff, 0xff, 0xff, 0xff}},
+		{-256, []byte{0xff, 0xff, 0xff, 0x00}},
+		{0x12345678, []byte{0x12, 0x34, 0x56, 0x78}},
+		{math.MaxInt32, []byte{0x7f, 0xff, 0xff, 0xff}},
+		{math.MinInt32, []byte{0x80, 0x00, 0x00, 0x00}},
+	}
+
+	for _, tt := range tests {
+		if b := EncodeInt32(tt.v); !bytes.Equal(b, tt.b) {
+			t.Errorf("EncodeInt32(%d) = %x, want %x", tt.v, b, tt.b)
+		}
+
+		v, err := DecodeInt32(tt.b)
+		if err != nil || v != tt.v {
+			t.Errorf("DecodeInt32(%x) = %d, %v, want %d", tt.b, v, err, tt.v)
+		}
+	}
+}
+
+func TestInt64Codec(t *testing.T) {
+	tests := []struct {
+		v int64
+		b []byte
+	}{
+		{0, []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}},
+		{1, []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01}},
+		{-1, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{-2, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xfe}},
+		{1432648000000, []byte{0x00, 0x00, 0x01, 0x4d, 0x90, 0x79, 0xa2, 0x00}},
+		{math.MaxInt64, []byte{0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{math.MinInt64, []byte{0x80, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}},
+	}
+
+	for _, tt := range tests {
+		if b := EncodeInt64(tt.v); !bytes.Equal(b, tt.b) {
+			t.Errorf("EncodeInt64(%d) = %x, want %x", tt.v, b, tt.b)
+		}
+
+		v, err := DecodeInt64(tt.b)
+		if err != nil || v != tt.v {
+			t.Errorf("DecodeInt64(%x) = %d, %v, want %d", tt.b, v, err, tt.v)
+		}
+	}
+}
+
+func TestFloat32Codec(t *testing.T) {
+	tests := []struct {
+		v float32
+		b []byte
+	}{
+		{0, []byte{0x00, 0x00, 0x00, 0x00}},
+		{float32(math.Copysign(0, -1)), []byte{0x80, 0x00, 0x00, 0x00}},
+		{1, []byte{0x3f, 0x80, 0x00, 0x00}},
+		{-2.5, []byte{0xc0, 0x20, 0x00, 0x00}},
+		{float32(math.Inf(1)), []byte{0x7f, 0x80, 0x00, 0x00}},
+		{float32(math.Inf(-1)), []byte{0xff, 0x80, 0x00, 0x00}},
+		// Java's Float.NaN
+		{math.Float32frombits(0x7fc00000), []byte{0x7f, 0xc0, 0x00, 0x00}},
+	}
+
+	for _, tt := range tests {
+		if b := EncodeFloat32(tt.v); !bytes.Equal(b, tt.b) {
+			t.Errorf("EncodeFloat32(%v) = %x, want %x", tt.v, b, tt.b)
+		}
+
+		v, err := DecodeFloat32(tt.b)
+		if err != nil || math.Float32bits(v) != math.Float32bits(tt.v) {
+			t.Errorf("DecodeFloat32(%x) = %v, %v, want %v", tt.b, v, err, tt.v)
+		}
+	}
+}
+
+func TestFloat64Codec(t *testing.T) {
+	tests := []struct {
+		v float64
+		b []byte
+	}{
+		{0, []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}},
+		{math.Copysign(0, -1), []byte{0x80, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}},
+		{1, []byte{0x3f, 0xf0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}},
+		{-2.5, []byte{0xc0, 0x04, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}},
+		{0.1, []byte{0x3f, 0xb9, 0x99, 0x99, 0x99, 0x99, 0x99, 0x9a}},
+		{math.Inf(-1), []byte{0xff, 0xf0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}},
+		// Java's Double.NaN
+		{math.Float64frombits(0x7ff8000000000000), []byte{0x7f, 0xf8, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}},
+	}
+
+	for _, tt := range tests {
+		if b := EncodeFloat64(tt.v); !bytes.Equal(b, tt.b) {
+			t.Errorf("EncodeFloat64(%v) = %x, want %x", tt.v, b, tt.b)
+		}
+
+		v, err := DecodeFloat64(tt.b)
+		if err != nil || math.Float64bits(v) != math.Float64bits(tt.v) {
+			t.Errorf("DecodeFloat64(%x) = %v, %v, want %v", tt.b, v, err, tt.v)
+		}
+	}
+}
+
+func TestBoolCodec(t *testing.T) {
+	if b := EncodeBool(true); !bytes.Equal(b, []byte{0xff}) {
+		t.Errorf("EncodeBool(true) = %x, want ff", b)
+	}
+	if b := EncodeBool(false); !bytes.Equal(b, []byte{0x00}) {
+		t.Errorf("EncodeBool(false) = %x, want 00", b)
+	}
+
+	tests := []struct {
+		b []byte
+		v bool
+	}{
+		{[]byte{0xff}, true},
+		{[]byte{0x00}, false},
+		// Bytes.toBoolean is true for any non zero byte
+		{[]byte{0x01}, true},
+	}
+
+	for _, tt := range tests {
+		v, err := DecodeBool(tt.b)
+		if err != nil || v != tt.v {
+			t.Errorf("DecodeBool(%x) = %v, %v, want %v", tt.b, v, err, tt.v)
+		}
+	}
+}
+
+func TestBigDecimalCodec(t *testing.T) {
+	tests := []struct {
+		s        string
+		unscaled int64
+		scale    int32
+		b        []byte
+	}{
+		{"0", 0, 0, []byte{0x00, 0x00, 0x00, 0x00, 0x00}},
+		{"1", 1, 0, []byte{0x00, 0x00, 0x00, 0x00, 0x01}},
+		{"-1", -1, 0, []byte{0x00, 0x00, 0x00, 0x00, 0xff}},
+		{"127", 127, 0, []byte{0x00, 0x00, 0x00, 0x00, 0x7f}},
+		{"128", 128, 0, []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x80}},
+		{"-128", -128, 0, []byte{0x00, 0x00, 0x00, 0x00, 0x80}},
+		{"-129", -129, 0, []byte{0x00, 0x00, 0x00, 0x00, 0xff, 0x7f}},
+		{"1.50", 150, 2, []byte{0x00, 0x00, 0x00, 0x02, 0x00, 0x96}},
+		{"-0.001", -1, 3, []byte{0x00, 0x00, 0x00, 0x03, 0xff}},
+		{"1E+3", 1, -3, []byte{0xff, 0xff, 0xff, 0xfd, 0x01}},
+		{"-2.5E+10", -25, -9, []byte{0xff, 0xff, 0xff, 0xf7, 0xe7}},
+	}
+
+	for _, tt := range tests {
+		d, err := ParseBigDecimal(tt.s)
+		if err != nil {
+			t.Errorf("ParseBigDecimal(%q) failed: %v", tt.s, err)
+			continue
+		}
+		if d.Unscaled.Int64() != tt.unscaled || d.Scale != tt.scale {
+			t.Errorf("ParseBigDecimal(%q) = %s scale %d, want %d scale %d", tt.s, d.Unscaled, d.Scale, tt.unscaled, tt.scale)
+		}
+
+		if b := EncodeBigDecimal(d); !bytes.Equal(b, tt.b) {
+			t.Errorf("EncodeBigDecimal(%s) = %x, want %x", tt.s, b, tt.b)
+		}
+
+		d, err = DecodeBigDecimal(tt.b)
+		if err != nil || d.Unscaled.Int64() != tt.unscaled || d.Scale != tt.scale {
+			t.Errorf("DecodeBigDecimal(%x) = %v, %v, want %d scale %d", tt.b, d, err, tt.unscaled, tt.scale)
+		}
+	}
+}
+
+func TestBigDecimalRoundTrip(t *testing.T) {
+	huge, _ := new(big.Int).SetString("-123456789012345678901234567890", 10)
+
+	tests := []*BigDecimal{
+		NewBigDecimal(big.NewInt(0), 5),
+		NewBigDecimal(big.NewInt(-32768), 0),
+		NewBigDecimal(big.NewInt(32767), -7),
+		NewBigDecimal(big.NewInt(math.MinInt64), 18),
+		NewBigDecimal(huge, 12),
+	}
+
+	for _, d := range tests {
+		got, err := DecodeBigDecimal(EncodeBigDecimal(d))
+		if err != nil {
+			t.Errorf("DecodeBigDecimal(EncodeBigDecimal(%s)) failed: %v", d, err)
+			continue
+		}
+		if got.Unscaled.Cmp(d.Unscaled) != 0 || got.Scale != d.Scale {
+			t.Errorf("DecodeBigDecimal(EncodeBigDecimal(%s)) = %s", d, got)
+		}
+	}
+}
+
+func TestBigDecimalString(t *testing.T) {
+	tests := []struct {
+		d *BigDecimal
+		s string
+	}{
+		{NewBigDecimal(big.NewInt(150), 2), "1.50"},
+		{NewBigDecimal(big.NewInt(-1), 3), "-0.001"},
+		{NewBigDecimal(big.NewInt(1), -3), "1000"},
+		{NewBigDecimal(big.NewInt(0), -3), "0"},
+	}
+
+	for _, tt := range tests {
+		if s := tt.d.String(); s != tt.s {
+			t.Errorf("String() = %q, want %q", s, tt.s)
+		}
+	}
+}
+
+func TestIntRoundTrip(t *testing.T) {
+	for _, v := range []int64{0, 1, -1, 255, -256, 1 << 40, -(1 << 40), math.MaxInt64, math.MinInt64} {
+		if got, err := DecodeInt64(EncodeInt64(v)); err != nil || got != v {
+			t.Errorf("int64 round trip of %d = %d, %v", v, got, err)
+		}
+		if got, err := DecodeInt32(EncodeInt32(int32(v))); err != nil || got != int32(v) {
+			t.Errorf("int32 round trip of %d = %d, %v", int32(v), got, err)
+		}
+		if got, err := DecodeInt16(EncodeInt16(int16(v))); err != nil || got != int16(v) {
+			t.Errorf("int16 round trip of %d = %d, %v", int16(v), got, err)
+		}
+	}
+}
+
+func TestDecodeInvalidLength(t *testing.T) {
+	if _, err := DecodeInt16([]byte{1}); err == nil {
+		t.Error("DecodeInt16 of 1 byte should fail")
+	}
+	if _, err := DecodeInt32([]byte{1, 2, 3}); err == nil {
+		t.Error("DecodeInt32 of 3 bytes should fail")
+	}
+	if _, err := DecodeInt64([]byte{1, 2, 3, 4}); err == nil {
+		t.Error("DecodeInt64 of 4 bytes should fail")
+	}
+	if _, err := DecodeBool(nil); err == nil {
+		t.Error("DecodeBool of no byte should fail")
+	}
+	if _, err := DecodeBigDecimal([]byte{0, 0, 0, 0}); err == nil {
+		t.Error("DecodeBigDecimal without unscaled value should fail")
+	}
+}
@@ -0,0 +1,125 @@
+package hbase
+
+import (
+	"strings"
+
+	"github.com/cugbliwei/go-hbase/proto"
+	pb "github.com/golang/protobuf/proto"
+)
+
+// Comparator is a ByteArrayComparable used by compare filters to match
+// rows, families, qualifiers and values.
+type Comparator interface {
+	toProto() *proto.Comparator
+}
+
+type comparator struct {
+	name string
+	msg  pb.Message
+}
+
+func (this *comparator) toProto() *proto.Comparator {
+	serialized, _ := pb.Marshal(this.msg)
+
+	return &proto.Comparator{
+		Name:                 pb.String(filterPackage + this.name),
+		SerializedComparator: serialized,
+	}
+}
+
+// NewBinaryComparator compares against value lexicographically.
+func NewBinaryComparator(value []byte) Comparator {
+	return &comparator{
+		name: "BinaryComparator",
+		msg: &proto.BinaryComparator{
+			Comparable: &proto.ByteArrayComparable{Value: value},
+		},
+	}
+}
+
+// NewBinaryPrefixComparator compares against the first len(prefix) bytes of the value.
+func NewBinaryPrefixComparator(prefix []byte) Comparator {
+	return &comparator{
+		name: "BinaryPrefixComparator",
+		msg: &proto.BinaryPrefixComparator{
+			Comparable: &proto.ByteArrayComparable{Value: prefix},
+		},
+	}
+}
+
+type BitwiseOp int32
+
+const (
+	BitwiseAnd BitwiseOp = BitwiseOp(proto.BitComparator_AND)
+	BitwiseOr  BitwiseOp = BitwiseOp(proto.BitComparator_OR)
+	BitwiseXor BitwiseOp = BitwiseOp(proto.BitComparator_XOR)
+)
+
+// NewBitComparator matches when value op'ed with the stored value is not
+// all zeroes. It only supports Equal and NotEqual.
+func NewBitComparator(value []byte, op BitwiseOp) Comparator {
+	return &comparator{
+		name: "BitComparator",
+		msg: &proto.BitComparator{
+			Comparable: &proto.ByteArrayComparable{Value: value},
+			BitwiseOp:  proto.BitComparator_BitwiseOp(op).Enum(),
+		},
+	}
+}
+
+// NewLongComparator compares values stored as 8 byte big-endian longs numerically.
+func NewLongComparator(value int64) Comparator {
+	b := make([]byte, 8)
+	byte_order.PutUint64(b, uint64(value))
+
+	return &comparator{
+		name: "LongComparator",
+		msg: &proto.LongComparator{
+			Comparable: &proto.ByteArrayComparable{Value: b},
+		},
+	}
+}
+
+// NewNullComparator matches empty or missing values.
+func NewNullComparator() Comparator {
+	return &comparator{
+		name: "NullComparator",
+		msg:  &proto.NullComparator{},
+	}
+}
+
+// java.util.regex.Pattern flags for NewRegexStringComparatorFlags
+const (
+	RegexCaseInsensitive int32 = 0x02
+	RegexMultiline       int32 = 0x08
+	RegexDotAll          int32 = 0x20
+)
+
+// NewRegexStringComparator matches values against a java regular expression.
+// It only supports Equal and NotEqual.
+func NewRegexStringComparator(pattern string) Comparator {
+	return NewRegexStringComparatorFlags(pattern, 0)
+}
+
+func NewRegexStringComparatorFlags(pattern string, flags int32) Comparator {
+	return &comparator{
+		name: "RegexStringComparator",
+		msg: &proto.RegexStringComparator{
+			Pattern:      pb.String(pattern),
+			PatternFlags: pb.Int32(flags),
+			Charset:      pb.String("UTF-8"),
+			Engine:       pb.String("JAVA"),
+		},
+	}
+}
+
+// NewSubstringComparator matches values containing substr, case insensitively.
+// It only supports Equal and NotEqual.
+func NewSubstringComparator(substr string) Comparator {
+	return &comparator{
+		name: "SubstringComparator",
+		msg: &proto.SubstringComparator{
+			Substr: pb.String(strings.ToLower(substr)),
+		},
+	}
+}
@@ -0,0 +1,88 @@
+package hbase
+
+import (
+	"testing"
+
+	"github.com/cugbliwei/go-hbase/proto"
+	pb "github.com/golang/protobuf/proto"
+)
+
+// decodeComparator decodes the payload of c, which must serialize as the comparator name.
+func decodeComparator(t *testing.T, c Comparator, name string, msg pb.Message) {
+	t.Helper()
+
+	p := c.toProto()
+	decodeSerialized(t, p.GetName(), name, p.GetSerializedComparator(), msg)
+}
+
+func TestBinaryComparatorProto(t *testing.T) {
+	var msg proto.BinaryComparator
+	decodeComparator(t, NewBinaryComparator([]byte("value")), "BinaryComparator", &msg)
+
+	if v := msg.GetComparable().GetValue(); string(v) != "value" {
+		t.Errorf("Value = %q, want %q", v, "value")
+	}
+}
+
+func TestBinaryPrefixComparatorProto(t *testing.T) {
+	var msg proto.BinaryPrefixComparator
+	decodeComparator(t, NewBinaryPrefixComparator([]byte("pre")), "BinaryPrefixComparator", &msg)
+
+	if v := msg.GetComparable().GetValue(); string(v) != "pre" {
+		t.Errorf("Value = %q, want %q", v, "pre")
+	}
+}
+
+func TestLongComparatorProto(t *testing.T) {
+	var msg proto.LongComparator
+	decodeComparator(t, NewLongComparator(-2), "LongComparator", &msg)
+
+	want := []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xfe}
+	if v := msg.GetComparable().GetValue(); string(v) != string(want) {
+		t.Errorf("Value = %x, want %x", v, want)
+	}
+}
+
+func TestNullComparatorProto(t *testing.T) {
+	var msg proto.NullComparator
+	decodeComparator(t, NewNullComparator(), "NullComparator", &msg)
+}
+
+func TestRegexStringComparatorProto(t *testing.T) {
+	var msg proto.RegexStringComparator
+	c := NewRegexStringComparatorFlags("^a.*", RegexCaseInsensitive|RegexDotAll)
+	decodeComparator(t, c, "RegexStringComparator", &msg)
+
+	if msg.GetPattern() != "^a.*" || msg.GetPatternFlags() != 0x22 {
+		t.Errorf("Pattern = %q, PatternFlags = %#x, want %q, 0x22", msg.GetPattern(), msg.GetPatternFlags(), "^a.*")
+	}
+
+	if msg.GetCharset() != "UTF-8" || msg.GetEngine() != "JAVA" {
+		t.Errorf("Charset = %q, Engine = %q, want UTF-8, JAVA", msg.GetCharset(), msg.GetEngine())
+	}
+}
+
+func TestSubstringComparatorProto(t *testing.T) {
+	var msg proto.SubstringComparator
+	decodeComparator(t, NewSubstringComparator("MiXed"), "SubstringComparator", &msg)
+
+	// the server lower cases the values, so the substring must be too
+	if msg.GetSubstr() != "mixed" {
+		t.Errorf("Substr = %q, want %q", msg.GetSubstr(), "mixed")
+	}
+}
+
+func TestNewConditionNilComparator(t *testing.T) {
+	cond := newCondition([]byte("r"), []byte("cf"), []byte("q"), Equal, nil)
+
+	var msg proto.BinaryComparator
+	decodeSerialized(t, cond.GetComparator().GetName(), "BinaryComparator", cond.GetComparator().GetSerializedComparator(), &msg)
+
+	if len(msg.GetComparable().GetValue()) != 0 {
+		t.Errorf("Value = %q, want empty", msg.GetComparable().GetValue())
+	}
+
+	if cond.GetCompareType() != proto.CompareType_EQUAL {
+		t.Errorf("CompareType = %v, want EQUAL", cond.GetCompareType())
+	}
+}
@@ -0,0 +1,52 @@
+package hbase
+
+import (
+	"github.com/cugbliwei/go-hbase/proto"
+	pb "github.com/golang/protobuf/proto"
+)
+
+type CompareOp int32
+
+const (
+	Less           CompareOp = CompareOp(proto.CompareType_LESS)
+	LessOrEqual    CompareOp = CompareOp(proto.CompareType_LESS_OR_EQUAL)
+	Equal          CompareOp = CompareOp(proto.CompareType_EQUAL)
+	NotEqual       CompareOp = CompareOp(proto.CompareType_NOT_EQUAL)
+	GreaterOrEqual CompareOp = CompareOp(proto.CompareType_GREATER_OR_EQUAL)
+	Greater        CompareOp = CompareOp(proto.CompareType_GREATER)
+	NoOp           CompareOp = CompareOp(proto.CompareType_NO_OP)
+)
+
+func (op CompareOp) toProto() *proto.CompareType {
+	return proto.CompareType(op).Enum()
+}
+
+// checkAndMutate wraps a mutation with the condition that must hold
+// on the server before it is applied.
+type checkAndMutate struct {
+	mutation  action
+	condition *proto.Condition
+}
+
+// newCondition builds a condition which compares the current value of
+// row/family:qualifier with cmp, like Java's checkAndMutate:
+// Less means the comparator value is less than the stored value.
+// A binary comparator with an empty value, or a nil cmp, matches only
+// when the cell does not exist.
+func newCondition(row, family, qualifier []byte, op CompareOp, cmp Comparator) *proto.Condition {
+	if cmp == nil {
+		cmp = NewBinaryComparator(nil)
+	}
+
+	return &proto.Condition{
+		Row:         row,
+		Family:      family,
+		Qualifier:   qualifier,
+		CompareType: op.toProto(),
+		Comparator:  cmp.toProto(),
+	}
+}
+
+func (this *checkAndMutate) toProto() pb.Message {
+	return this.mutation.toProto()
+}
@@ -1,9 +1,12 @@
 package hbase
 
 import (
+	"context"
 	"fmt"
 	"net"
+	"sync"
 
+	"github.com/cugbliwei/dlog"
 	"github.com/cugbliwei/go-hbase/proto"
 	pb "github.com/golang/protobuf/proto"
 )
@@ -18,8 +21,9 @@ type connection struct {
 	socket net.Conn
 	in     *inputStream
 
-	calls  map[int]*call
-	callId *atomicCounter
+	calls     map[int]*call
+	callsLock *sync.Mutex
+	callId    *atomicCounter
 
 	isMaster bool
 }
@@ -45,8 +49,9 @@ func newConnection(connstr, user string, isMaster bool) (*connection, error) {
 		socket: socket,
 		in:     newInputStream(socket),
 
-		calls:  make(map[int]*call),
-		callId: newAtomicCounter(),
+		calls:     make(map[int]*call),
+		callsLock: &sync.Mutex{},
+		callId:    newAtomicCounter(),
 
 		isMaster: isMaster,
 	}
@@ -141,20 +146,46 @@ func (c *connection) call(request *call) error {
 	buf := newOutputBuffer()
 	buf.writeDelimitedBuffers(bfrh, bfr)
 
+	c.callsLock.Lock()
 	c.calls[id] = request
+	c.callsLock.Unlock()
+
 	n, err := c.socket.Write(buf.Bytes())
 
 	if err != nil {
+		c.cancel(request)
 		return err
 	}
 
 	if n != len(buf.Bytes()) {
+		c.cancel(request)
 		return fmt.Errorf("Sent bytes not match number bytes [n=%d] [actual_n=%d]", n, len(buf.Bytes()))
 	}
 
 	return nil
 }
 
+// cancel drops a pending call, its response will be ignored.
+func (c *connection) cancel(request *call) {
+	c.callsLock.Lock()
+	delete(c.calls, int(request.id))
+	c.callsLock.Unlock()
+}
+
+// wait blocks until request completes or ctx is done. In the latter case
+// the call is cancelled and an exception is returned.
+func (c *connection) wait(ctx context.Context, request *call) pb.Message {
+	select {
+	case r := <-request.responseCh:
+		return r
+	case <-ctx.Done():
+		c.cancel(request)
+		return &exception{
+			msg: ctx.Err().Error(),
+		}
+	}
+}
+
 func (c *connection) processMessages() {
 	for {
 		msgs := c.in.processData()
@@ -169,16 +200,21 @@ func (c *connection) processMessages() {
 		}
 
 		callId := rh.GetCallId()
+
+		c.callsLock.Lock()
 		call, ok := c.calls[int(callId)]
+		delete(c.calls, int(callId))
+		c.callsLock.Unlock()
+
 		if !ok {
-			panic(fmt.Errorf("Invalid call id: %d", callId))
+			// the call was cancelled
+			dlog.Warn("Ignoring response for unknown call id: %d", callId)
+			continue
 		}
 
-		delete(c.calls, int(callId))
-
 		exception := rh.GetException()
 		if exception != nil {
-			call.complete(fmt.Errorf("Exception returned: %s\n%s", exception.GetExceptionClassName(), exception.GetStackTrace()), nil)
+			call.complete(fmt.Errorf("%s%s\n%s", server_exception_prefix, exception.GetExceptionClassName(), exception.GetStackTrace()), nil)
 		} else if len(msgs) == 2 {
 			call.complete(nil, msgs[1])
 		}
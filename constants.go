@@ -16,8 +16,10 @@ const ping_timeout = 30000
 const call_timeout = 5000
 const socket_retry_wait_ms = 200
 const max_action_retries = 3
+const coprocessor_concurrency = 16
+const server_exception_prefix = "Exception returned: "
 
 var byte_order binary.ByteOrder = binary.BigEndian
 var hbase_header_bytes []byte = []byte("HBas")
-var meta_table_name []byte = []byte("hbase:meta")
+var meta_table_name TableName = NewTableName(system_namespace, "meta")
 var meta_region_name []byte = []byte("hbase:meta,,1")
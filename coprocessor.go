@@ -0,0 +1,307 @@
+package hbase
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/cugbliwei/dlog"
+	"github.com/cugbliwei/go-hbase/proto"
+	pb "github.com/golang/protobuf/proto"
+)
+
+// coprocessorCall invokes method of a coprocessor endpoint service, at
+// the region of the row the action is sent for.
+type coprocessorCall struct {
+	service string
+	method  string
+	request []byte
+}
+
+func newCoprocessorCall(service, method string, request pb.Message) (*coprocessorCall, error) {
+	b, err := pb.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	return &coprocessorCall{
+		service: service,
+		method:  method,
+		request: b,
+	}, nil
+}
+
+func (this *coprocessorCall) toProto() pb.Message {
+	return &proto.CoprocessorServiceCall{
+		Row:         []byte{},
+		ServiceName: pb.String(this.service),
+		MethodName:  pb.String(this.method),
+		Request:     this.request,
+	}
+}
+
+// coprocessorResponse decodes the endpoint's reply carried by the
+// ExecService response into response.
+func coprocessorResponse(ctx context.Context, r pb.Message, response pb.Message) error {
+	switch rs := r.(type) {
+	case *proto.CoprocessorServiceResponse:
+		return pb.Unmarshal(rs.GetValue().GetValue(), response)
+	case *exception:
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return fmt.Errorf("Coprocessor call failed [err: %s]", rs.msg)
+	}
+
+	return responseError(ctx, r)
+}
+
+// CoprocessorResult is the reply of one region to a fan-out coprocessor
+// call, see CoprocessorExecRange.
+type CoprocessorResult struct {
+	Region   string
+	StartKey []byte
+	EndKey   []byte
+	Response pb.Message
+	Err      error
+}
+
+// CoprocessorExec invokes method of the coprocessor endpoint service on
+// the region holding row, and decodes the reply into response.
+func (c *Client) CoprocessorExec(table string, row []byte, service, method string, request, response pb.Message) error {
+	return c.CoprocessorExecContext(context.Background(), table, row, service, method, request, response)
+}
+
+func (c *Client) CoprocessorExecContext(ctx context.Context, table string, row []byte, service, method string, request, response pb.Message) error {
+	call, err := newCoprocessorCall(service, method, request)
+	if err != nil {
+		return err
+	}
+
+	ch := c.action(ctx, ParseTableName(table), row, call, true, 0)
+
+	return coprocessorResponse(ctx, <-ch, response)
+}
+
+// CoprocessorExecRange invokes method on every region overlapping
+// [startRow, stopRow), empty rows are unbounded. Regions are called in
+// parallel, at most SetCoprocessorConcurrency at once, newResponse makes
+// the message each reply is decoded into. Results are in region order, a
+// region which split or moved meanwhile is relocated and its part of the
+// range called on the regions now holding it. A failed region only sets
+// its Err.
+func (c *Client) CoprocessorExecRange(table string, startRow, stopRow []byte, service, method string, request pb.Message, newResponse func() pb.Message) ([]*CoprocessorResult, error) {
+	return c.CoprocessorExecRangeContext(context.Background(), table, startRow, stopRow, service, method, request, newResponse)
+}
+
+func (c *Client) CoprocessorExecRangeContext(ctx context.Context, table string, startRow, stopRow []byte, service, method string, request pb.Message, newResponse func() pb.Message) ([]*CoprocessorResult, error) {
+	call, err := newCoprocessorCall(service, method, request)
+	if err != nil {
+		return nil, err
+	}
+
+	t := ParseTableName(table)
+
+	regions, err := c.regionsInRange(ctx, t, startRow, stopRow)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(regions) == 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("No regions found [table: %s] [start: %q] [stop: %q]", table, startRow, stopRow)
+	}
+
+	slots := make(chan struct{}, c.coprocessorConcurrency)
+	results := c.coprocessorExecRegions(ctx, t, regions, startRow, stopRow, call, newResponse, slots, 0)
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// SetCoprocessorConcurrency bounds the regions called at once by
+// CoprocessorExecRange, and the aggregations built on it.
+func (c *Client) SetCoprocessorConcurrency(n int) {
+	if n > 0 {
+		c.coprocessorConcurrency = n
+	}
+}
+
+// coprocessorExecRegions calls every region of [startRow, stopRow), each
+// holding one of slots during its call.
+func (c *Client) coprocessorExecRegions(ctx context.Context, table TableName, regions []*regionInfo, startRow, stopRow []byte, call *coprocessorCall, newResponse func() pb.Message, slots chan struct{}, retries int) []*CoprocessorResult {
+	results := make([][]*CoprocessorResult, len(regions))
+
+	wg := &sync.WaitGroup{}
+	for i, region := range regions {
+		slots <- struct{}{}
+
+		wg.Add(1)
+		go func(i int, region *regionInfo) {
+			defer wg.Done()
+
+			r, moved := c.regionCoprocessorExec(ctx, region, call)
+			<-slots
+
+			if moved && retries < c.maxRetries && ctx.Err() == nil {
+				start, stop := clipRange(region, startRow, stopRow)
+				if relocated := c.relocateRange(ctx, table, start, stop); relocated != nil {
+					dlog.Info("region %s moved, retrying coprocessor call for the %d time", region.name, retries+1)
+					results[i] = c.coprocessorExecRegions(ctx, table, relocated, start, stop, call, newResponse, slots, retries+1)
+					return
+				}
+			}
+
+			res := &CoprocessorResult{
+				Region:   region.name,
+				StartKey: region.startKey,
+				EndKey:   region.endKey,
+				Response: newResponse(),
+			}
+			res.Err = coprocessorResponse(ctx, r, res.Response)
+
+			results[i] = []*CoprocessorResult{res}
+		}(i, region)
+	}
+	wg.Wait()
+
+	tbr := make([]*CoprocessorResult, 0, len(regions))
+	for _, rs := range results {
+		tbr = append(tbr, rs...)
+	}
+
+	return tbr
+}
+
+// regionCoprocessorExec sends call to region itself rather than to the
+// region holding a row, so a region which split or moved since it was
+// located fails instead of covering only part of its range. moved tells
+// whether the region must be relocated.
+func (c *Client) regionCoprocessorExec(ctx context.Context, region *regionInfo, call *coprocessorCall) (r pb.Message, moved bool) {
+	pc := call.toProto().(*proto.CoprocessorServiceCall)
+	pc.Row = region.startKey
+
+	cl := newCall(&proto.CoprocessorServiceRequest{
+		Region: &proto.RegionSpecifier{
+			Type:  proto.RegionSpecifier_REGION_NAME.Enum(),
+			Value: []byte(region.name),
+		},
+		Call: pc,
+	})
+
+	conn, err := c.getRegionConnection(region.server)
+	if err != nil {
+		// the server is gone, its regions are reassigned
+		return &exception{msg: err.Error()}, true
+	}
+
+	if err := conn.call(cl); err != nil {
+		c.purgeServer(region.server)
+		// the server is gone, its regions are reassigned
+		return &exception{msg: err.Error()}, true
+	}
+
+	r = conn.wait(ctx, cl)
+	if e, ok := r.(*exception); ok {
+		return r, regionMoved(e.msg)
+	}
+
+	return r, false
+}
+
+// relocateRange looks up the current regions of [startRow, stopRow) in
+// meta, bypassing the cache. It returns nil if one can't be located.
+func (c *Client) relocateRange(ctx context.Context, table TableName, startRow, stopRow []byte) []*regionInfo {
+	regions := make([]*regionInfo, 0)
+
+	row := startRow
+	for {
+		region, err := c.locateRegion(ctx, table, row, false)
+		if err != nil {
+			return nil
+		}
+
+		regions = append(regions, region)
+
+		if len(region.endKey) == 0 || (len(stopRow) > 0 && bytes.Compare(region.endKey, stopRow) >= 0) {
+			return regions
+		}
+
+		// meta is being updated, don't loop on it
+		if bytes.Compare(region.endKey, row) <= 0 {
+			return nil
+		}
+
+		row = region.endKey
+	}
+}
+
+// clipRange returns the part of [startRow, stopRow) held by region.
+func clipRange(region *regionInfo, startRow, stopRow []byte) ([]byte, []byte) {
+	start, stop := startRow, stopRow
+
+	if bytes.Compare(region.startKey, start) > 0 {
+		start = region.startKey
+	}
+
+	if len(region.endKey) > 0 && (len(stop) == 0 || bytes.Compare(region.endKey, stop) < 0) {
+		stop = region.endKey
+	}
+
+	return start, stop
+}
+
+// RegionServerCoprocessorExec invokes method of a region server
+// coprocessor service on server, given as "host:port".
+func (c *Client) RegionServerCoprocessorExec(server, service, method string, request, response pb.Message) error {
+	return c.RegionServerCoprocessorExecContext(context.Background(), server, service, method, request, response)
+}
+
+func (c *Client) RegionServerCoprocessorExecContext(ctx context.Context, server, service, method string, request, response pb.Message) error {
+	return c.serviceExec(ctx, server, false, "ExecRegionServerService", service, method, request, response)
+}
+
+// MasterCoprocessorExec invokes method of a master coprocessor service.
+func (c *Client) MasterCoprocessorExec(service, method string, request, response pb.Message) error {
+	return c.MasterCoprocessorExecContext(context.Background(), service, method, request, response)
+}
+
+func (c *Client) MasterCoprocessorExecContext(ctx context.Context, service, method string, request, response pb.Message) error {
+	return c.serviceExec(ctx, c.getServerName(c.masterServer), true, "ExecMasterService", service, method, request, response)
+}
+
+// serviceExec sends a coprocessor call which is not bound to a region.
+// The region specifier is required by the message but ignored.
+func (c *Client) serviceExec(ctx context.Context, server string, isMaster bool, rpc, service, method string, request, response pb.Message) error {
+	call, err := newCoprocessorCall(service, method, request)
+	if err != nil {
+		return err
+	}
+
+	conn, err := c.getConnection(server, isMaster)
+	if err != nil {
+		return err
+	}
+
+	cl := newCall(&proto.CoprocessorServiceRequest{
+		Region: &proto.RegionSpecifier{
+			Type:  proto.RegionSpecifier_REGION_NAME.Enum(),
+			Value: []byte{},
+		},
+		Call: call.toProto().(*proto.CoprocessorServiceCall),
+	})
+	cl.methodName = rpc
+
+	if err := conn.call(cl); err != nil {
+		c.purgeServer(server)
+		return err
+	}
+
+	return coprocessorResponse(ctx, conn.wait(ctx, cl), response)
+}
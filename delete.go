@@ -11,16 +11,31 @@ import (
 )
 
 type Delete struct {
-	key        []byte
-	families   [][]byte
-	qualifiers [][][]byte
+	mutationOptions
+
+	key         []byte
+	timestamp   int64
+	families    [][]byte
+	qualifiers  [][][]byte
+	timestamps  [][]int64
+	deleteTypes [][]proto.MutationProto_DeleteType
 }
 
 func CreateNewDelete(key []byte) *Delete {
+	return CreateNewDeleteTS(key, math.MaxInt64)
+}
+
+// CreateNewDeleteTS deletes the whole row up to and including ts.
+// ts is also the default timestamp of the columns and families added
+// without one.
+func CreateNewDeleteTS(key []byte, ts int64) *Delete {
 	return &Delete{
-		key:        key,
-		families:   make([][]byte, 0),
-		qualifiers: make([][][]byte, 0),
+		key:         key,
+		timestamp:   ts,
+		families:    make([][]byte, 0),
+		qualifiers:  make([][][]byte, 0),
+		timestamps:  make([][]int64, 0),
+		deleteTypes: make([][]proto.MutationProto_DeleteType, 0),
 	}
 }
 
@@ -46,21 +61,56 @@ func (this *Delete) AddStringFamily(family string) {
 	this.AddFamily([]byte(family))
 }
 
+// AddColumn deletes all versions of family:qual.
 func (this *Delete) AddColumn(family, qual []byte) {
-	this.AddFamily(family)
+	this.AddColumnTS(family, qual, this.timestamp)
+}
 
-	pos := this.posOfFamily(family)
+// AddColumnTS deletes all versions of family:qual up to and including ts.
+func (this *Delete) AddColumnTS(family, qual []byte, ts int64) {
+	this.add(family, qual, ts, proto.MutationProto_DELETE_MULTIPLE_VERSIONS)
+}
 
-	this.qualifiers[pos] = append(this.qualifiers[pos], qual)
+// AddColumnLatestVersion deletes only the latest version of family:qual.
+func (this *Delete) AddColumnLatestVersion(family, qual []byte) {
+	this.add(family, qual, math.MaxInt64, proto.MutationProto_DELETE_ONE_VERSION)
 }
 
+// AddColumnVersion deletes only the version of family:qual written at ts.
+func (this *Delete) AddColumnVersion(family, qual []byte, ts int64) {
+	this.add(family, qual, ts, proto.MutationProto_DELETE_ONE_VERSION)
+}
+
+// AddFamily deletes all versions of all columns of family.
 func (this *Delete) AddFamily(family []byte) {
+	this.AddFamilyTS(family, this.timestamp)
+}
+
+// AddFamilyTS deletes all versions of all columns of family up to and including ts.
+func (this *Delete) AddFamilyTS(family []byte, ts int64) {
+	this.add(family, nil, ts, proto.MutationProto_DELETE_FAMILY)
+}
+
+// AddFamilyVersion deletes the versions of all columns of family written at exactly ts.
+func (this *Delete) AddFamilyVersion(family []byte, ts int64) {
+	this.add(family, nil, ts, proto.MutationProto_DELETE_FAMILY_VERSION)
+}
+
+func (this *Delete) add(family, qual []byte, ts int64, deleteType proto.MutationProto_DeleteType) {
 	pos := this.posOfFamily(family)
 
 	if pos == -1 {
 		this.families = append(this.families, family)
 		this.qualifiers = append(this.qualifiers, make([][]byte, 0))
+		this.timestamps = append(this.timestamps, make([]int64, 0))
+		this.deleteTypes = append(this.deleteTypes, make([]proto.MutationProto_DeleteType, 0))
+
+		pos = this.posOfFamily(family)
 	}
+
+	this.qualifiers[pos] = append(this.qualifiers[pos], qual)
+	this.timestamps[pos] = append(this.timestamps[pos], ts)
+	this.deleteTypes[pos] = append(this.deleteTypes[pos], deleteType)
 }
 
 func (this *Delete) posOfFamily(family []byte) int {
@@ -72,36 +122,39 @@ func (this *Delete) posOfFamily(family []byte) int {
 	return -1
 }
 
+func (this *Delete) row() []byte {
+	return this.key
+}
+
 func (this *Delete) toProto() pb.Message {
 	d := &proto.MutationProto{
 		Row:        this.key,
 		MutateType: proto.MutationProto_DELETE.Enum(),
 	}
 
+	// without any column the whole row is deleted up to the row timestamp
+	if this.timestamp != math.MaxInt64 {
+		d.Timestamp = pb.Uint64(uint64(this.timestamp))
+	}
+
 	for i, v := range this.families {
 		cv := &proto.MutationProto_ColumnValue{
 			Family:         v,
 			QualifierValue: make([]*proto.MutationProto_ColumnValue_QualifierValue, 0),
 		}
 
-		if len(this.qualifiers[i]) == 0 {
-			cv.QualifierValue = append(cv.QualifierValue, &proto.MutationProto_ColumnValue_QualifierValue{
-				Qualifier:  nil,
-				Timestamp:  pb.Uint64(uint64(math.MaxInt64)),
-				DeleteType: proto.MutationProto_DELETE_FAMILY.Enum(),
-			})
-		}
-
-		for _, v := range this.qualifiers[i] {
+		for j, q := range this.qualifiers[i] {
 			cv.QualifierValue = append(cv.QualifierValue, &proto.MutationProto_ColumnValue_QualifierValue{
-				Qualifier:  v,
-				Timestamp:  pb.Uint64(uint64(math.MaxInt64)),
-				DeleteType: proto.MutationProto_DELETE_MULTIPLE_VERSIONS.Enum(),
+				Qualifier:  q,
+				Timestamp:  pb.Uint64(uint64(this.timestamps[i][j])),
+				DeleteType: this.deleteTypes[i][j].Enum(),
 			})
 		}
 
 		d.ColumnValue = append(d.ColumnValue, cv)
 	}
 
+	this.applyTo(d)
+
 	return d
 }
@@ -0,0 +1,372 @@
+package hbase
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/cugbliwei/go-hbase/proto"
+	pb "github.com/golang/protobuf/proto"
+)
+
+// filterPackage is the Java package of the filters and comparators.
+const filterPackage = "org.apache.hadoop.hbase.filter."
+
+// Filter is a server side filter which can be attached to a Get or Scan.
+type Filter interface {
+	toProto() *proto.Filter
+}
+
+type filter struct {
+	name string
+	msg  pb.Message
+}
+
+func (this *filter) toProto() *proto.Filter {
+	serialized, _ := pb.Marshal(this.msg)
+
+	return &proto.Filter{
+		Name:             pb.String(filterPackage + this.name),
+		SerializedFilter: serialized,
+	}
+}
+
+func newCompareFilter(op CompareOp, cmp Comparator) *proto.CompareFilter {
+	cf := &proto.CompareFilter{
+		CompareOp: op.toProto(),
+	}
+
+	if cmp != nil {
+		cf.Comparator = cmp.toProto()
+	}
+
+	return cf
+}
+
+func NewColumnCountGetFilter(limit int32) Filter {
+	return &filter{
+		name: "ColumnCountGetFilter",
+		msg:  &proto.ColumnCountGetFilter{Limit: pb.Int32(limit)},
+	}
+}
+
+func NewColumnPaginationFilter(limit, offset int32) Filter {
+	return &filter{
+		name: "ColumnPaginationFilter",
+		msg: &proto.ColumnPaginationFilter{
+			Limit:  pb.Int32(limit),
+			Offset: pb.Int32(offset),
+		},
+	}
+}
+
+// NewColumnPaginationFilterFrom returns at most limit columns starting
+// with the column columnOffset.
+func NewColumnPaginationFilterFrom(limit int32, columnOffset []byte) Filter {
+	return &filter{
+		name: "ColumnPaginationFilter",
+		msg: &proto.ColumnPaginationFilter{
+			Limit:        pb.Int32(limit),
+			ColumnOffset: columnOffset,
+		},
+	}
+}
+
+func NewColumnPrefixFilter(prefix []byte) Filter {
+	return &filter{
+		name: "ColumnPrefixFilter",
+		msg:  &proto.ColumnPrefixFilter{Prefix: prefix},
+	}
+}
+
+// NewColumnRangeFilter selects the columns between minColumn and maxColumn.
+// A nil bound is unbounded.
+func NewColumnRangeFilter(minColumn []byte, minInclusive bool, maxColumn []byte, maxInclusive bool) Filter {
+	return &filter{
+		name: "ColumnRangeFilter",
+		msg: &proto.ColumnRangeFilter{
+			MinColumn:          minColumn,
+			MinColumnInclusive: pb.Bool(minInclusive),
+			MaxColumn:          maxColumn,
+			MaxColumnInclusive: pb.Bool(maxInclusive),
+		},
+	}
+}
+
+// NewDependentColumnFilter keeps only the cells with the same timestamp as
+// family:qualifier. cmp may be nil to skip the value check.
+func NewDependentColumnFilter(family, qualifier []byte, dropDependentColumn bool, op CompareOp, cmp Comparator) Filter {
+	return &filter{
+		name: "DependentColumnFilter",
+		msg: &proto.DependentColumnFilter{
+			CompareFilter:       newCompareFilter(op, cmp),
+			ColumnFamily:        family,
+			ColumnQualifier:     qualifier,
+			DropDependentColumn: pb.Bool(dropDependentColumn),
+		},
+	}
+}
+
+func NewFamilyFilter(op CompareOp, cmp Comparator) Filter {
+	return &filter{
+		name: "FamilyFilter",
+		msg:  &proto.FamilyFilter{CompareFilter: newCompareFilter(op, cmp)},
+	}
+}
+
+func NewFirstKeyOnlyFilter() Filter {
+	return &filter{
+		name: "FirstKeyOnlyFilter",
+		msg:  &proto.FirstKeyOnlyFilter{},
+	}
+}
+
+func NewFirstKeyValueMatchingQualifiersFilter(qualifiers ...[]byte) Filter {
+	return &filter{
+		name: "FirstKeyValueMatchingQualifiersFilter",
+		msg:  &proto.FirstKeyValueMatchingQualifiersFilter{Qualifiers: qualifiers},
+	}
+}
+
+// FuzzyKey is a row key template for NewFuzzyRowFilter. Mask has the
+// same length as Row: 0 marks a fixed byte, 1 marks a byte that may be anything.
+type FuzzyKey struct {
+	Row  []byte
+	Mask []byte
+}
+
+func NewFuzzyRowFilter(keys ...FuzzyKey) Filter {
+	pairs := make([]*proto.BytesBytesPair, len(keys))
+	for i, k := range keys {
+		pairs[i] = &proto.BytesBytesPair{
+			First:  k.Row,
+			Second: k.Mask,
+		}
+	}
+
+	return &filter{
+		name: "FuzzyRowFilter",
+		msg:  &proto.FuzzyRowFilter{FuzzyKeysData: pairs},
+	}
+}
+
+func NewInclusiveStopFilter(stopRow []byte) Filter {
+	return &filter{
+		name: "InclusiveStopFilter",
+		msg:  &proto.InclusiveStopFilter{StopRowKey: stopRow},
+	}
+}
+
+// NewKeyOnlyFilter strips values, replacing them by their length when lenAsVal is set.
+func NewKeyOnlyFilter(lenAsVal bool) Filter {
+	return &filter{
+		name: "KeyOnlyFilter",
+		msg:  &proto.KeyOnlyFilter{LenAsVal: pb.Bool(lenAsVal)},
+	}
+}
+
+func NewMultipleColumnPrefixFilter(prefixes ...[]byte) Filter {
+	// the server expects the prefixes sorted and without duplicates
+	sorted := make([][]byte, 0, len(prefixes))
+	sorted = append(sorted, prefixes...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i], sorted[j]) < 0
+	})
+
+	uniq := make([][]byte, 0, len(sorted))
+	for i, p := range sorted {
+		if i == 0 || !bytes.Equal(p, sorted[i-1]) {
+			uniq = append(uniq, p)
+		}
+	}
+
+	return &filter{
+		name: "MultipleColumnPrefixFilter",
+		msg:  &proto.MultipleColumnPrefixFilter{SortedPrefixes: uniq},
+	}
+}
+
+// NewPageFilter limits the number of rows returned by each region.
+func NewPageFilter(pageSize int64) Filter {
+	return &filter{
+		name: "PageFilter",
+		msg:  &proto.PageFilter{PageSize: pb.Int64(pageSize)},
+	}
+}
+
+func NewPrefixFilter(prefix []byte) Filter {
+	return &filter{
+		name: "PrefixFilter",
+		msg:  &proto.PrefixFilter{Prefix: prefix},
+	}
+}
+
+func NewQualifierFilter(op CompareOp, cmp Comparator) Filter {
+	return &filter{
+		name: "QualifierFilter",
+		msg:  &proto.QualifierFilter{CompareFilter: newCompareFilter(op, cmp)},
+	}
+}
+
+// NewRandomRowFilter includes each row with the given chance, between 0 and 1.
+func NewRandomRowFilter(chance float32) Filter {
+	return &filter{
+		name: "RandomRowFilter",
+		msg:  &proto.RandomRowFilter{Chance: pb.Float32(chance)},
+	}
+}
+
+func NewRowFilter(op CompareOp, cmp Comparator) Filter {
+	return &filter{
+		name: "RowFilter",
+		msg:  &proto.RowFilter{CompareFilter: newCompareFilter(op, cmp)},
+	}
+}
+
+// SingleColumnValueFilter filters rows on the value of one column.
+type SingleColumnValueFilter struct {
+	family            []byte
+	qualifier         []byte
+	op                CompareOp
+	cmp               Comparator
+	filterIfMissing   bool
+	latestVersionOnly bool
+	exclude           bool
+}
+
+func NewSingleColumnValueFilter(family, qualifier []byte, op CompareOp, cmp Comparator) *SingleColumnValueFilter {
+	return &SingleColumnValueFilter{
+		family:            family,
+		qualifier:         qualifier,
+		op:                op,
+		cmp:               cmp,
+		latestVersionOnly: true,
+	}
+}
+
+// NewSingleColumnValueExcludeFilter works like NewSingleColumnValueFilter
+// but does not return the tested column.
+func NewSingleColumnValueExcludeFilter(family, qualifier []byte, op CompareOp, cmp Comparator) *SingleColumnValueFilter {
+	f := NewSingleColumnValueFilter(family, qualifier, op, cmp)
+	f.exclude = true
+	return f
+}
+
+// SetFilterIfMissing drops rows which do not have the column at all.
+func (this *SingleColumnValueFilter) SetFilterIfMissing(v bool) {
+	this.filterIfMissing = v
+}
+
+// SetLatestVersionOnly tests only the latest version of the column, the default.
+func (this *SingleColumnValueFilter) SetLatestVersionOnly(v bool) {
+	this.latestVersionOnly = v
+}
+
+func (this *SingleColumnValueFilter) toProto() *proto.Filter {
+	scvf := &proto.SingleColumnValueFilter{
+		ColumnFamily:      this.family,
+		ColumnQualifier:   this.qualifier,
+		CompareOp:         this.op.toProto(),
+		FilterIfMissing:   pb.Bool(this.filterIfMissing),
+		LatestVersionOnly: pb.Bool(this.latestVersionOnly),
+	}
+
+	if this.cmp != nil {
+		scvf.Comparator = this.cmp.toProto()
+	}
+
+	if this.exclude {
+		return (&filter{
+			name: "SingleColumnValueExcludeFilter",
+			msg:  &proto.SingleColumnValueExcludeFilter{SingleColumnValueFilter: scvf},
+		}).toProto()
+	}
+
+	return (&filter{
+		name: "SingleColumnValueFilter",
+		msg:  scvf,
+	}).toProto()
+}
+
+// NewSkipFilter drops a whole row as soon as f filters out one of its cells.
+func NewSkipFilter(f Filter) Filter {
+	return &filter{
+		name: "SkipFilter",
+		msg:  &proto.SkipFilter{Filter: f.toProto()},
+	}
+}
+
+func NewTimestampsFilter(timestamps ...int64) Filter {
+	sorted := make([]int64, 0, len(timestamps))
+	sorted = append(sorted, timestamps...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+
+	return &filter{
+		name: "TimestampsFilter",
+		msg:  &proto.TimestampsFilter{Timestamps: sorted},
+	}
+}
+
+func NewValueFilter(op CompareOp, cmp Comparator) Filter {
+	return &filter{
+		name: "ValueFilter",
+		msg:  &proto.ValueFilter{CompareFilter: newCompareFilter(op, cmp)},
+	}
+}
+
+// NewWhileMatchFilter stops the scan as soon as f filters out a cell.
+func NewWhileMatchFilter(f Filter) Filter {
+	return &filter{
+		name: "WhileMatchFilter",
+		msg:  &proto.WhileMatchFilter{Filter: f.toProto()},
+	}
+}
+
+func NewFilterAllFilter() Filter {
+	return &filter{
+		name: "FilterAllFilter",
+		msg:  &proto.FilterAllFilter{},
+	}
+}
+
+type FilterListOperator int32
+
+const (
+	MustPassAll FilterListOperator = FilterListOperator(proto.FilterList_MUST_PASS_ALL)
+	MustPassOne FilterListOperator = FilterListOperator(proto.FilterList_MUST_PASS_ONE)
+)
+
+// FilterList combines filters with MustPassAll (and) or MustPassOne (or).
+// A FilterList is itself a Filter, so lists can be nested.
+type FilterList struct {
+	operator FilterListOperator
+	filters  []Filter
+}
+
+func NewFilterList(operator FilterListOperator, filters ...Filter) *FilterList {
+	return &FilterList{
+		operator: operator,
+		filters:  filters,
+	}
+}
+
+func (this *FilterList) AddFilter(f Filter) {
+	this.filters = append(this.filters, f)
+}
+
+func (this *FilterList) toProto() *proto.Filter {
+	fl := &proto.FilterList{
+		Operator: proto.FilterList_Operator(this.operator).Enum(),
+		Filters:  make([]*proto.Filter, len(this.filters)),
+	}
+
+	for i, f := range this.filters {
+		fl.Filters[i] = f.toProto()
+	}
+
+	return (&filter{
+		name: "FilterList",
+		msg:  fl,
+	}).toProto()
+}
@@ -0,0 +1,155 @@
+package hbase
+
+import (
+	"testing"
+
+	"github.com/cugbliwei/go-hbase/proto"
+	pb "github.com/golang/protobuf/proto"
+)
+
+// decodeSerialized checks the java class name of a serialized filter or
+// comparator and decodes its payload into msg.
+func decodeSerialized(t *testing.T, className, name string, serialized []byte, msg pb.Message) {
+	t.Helper()
+
+	if className != filterPackage+name {
+		t.Fatalf("class name = %q, want %q", className, filterPackage+name)
+	}
+
+	if err := pb.Unmarshal(serialized, msg); err != nil {
+		t.Fatalf("decoding %s failed: %v", name, err)
+	}
+}
+
+// decodeFilter decodes the payload of f, which must serialize as the filter name.
+func decodeFilter(t *testing.T, f Filter, name string, msg pb.Message) {
+	t.Helper()
+
+	p := f.toProto()
+	decodeSerialized(t, p.GetName(), name, p.GetSerializedFilter(), msg)
+}
+
+func TestPrefixFilterProto(t *testing.T) {
+	var msg proto.PrefixFilter
+	decodeFilter(t, NewPrefixFilter([]byte("row")), "PrefixFilter", &msg)
+
+	if string(msg.GetPrefix()) != "row" {
+		t.Errorf("Prefix = %q, want %q", msg.GetPrefix(), "row")
+	}
+}
+
+func TestCompareFilterProto(t *testing.T) {
+	var msg proto.RowFilter
+	decodeFilter(t, NewRowFilter(GreaterOrEqual, NewBinaryComparator([]byte("b"))), "RowFilter", &msg)
+
+	cf := msg.GetCompareFilter()
+	if cf.GetCompareOp() != proto.CompareType_GREATER_OR_EQUAL {
+		t.Errorf("CompareOp = %v, want GREATER_OR_EQUAL", cf.GetCompareOp())
+	}
+
+	if cf.GetComparator().GetName() != filterPackage+"BinaryComparator" {
+		t.Errorf("Comparator = %q, want BinaryComparator", cf.GetComparator().GetName())
+	}
+}
+
+func TestCompareFilterNilComparator(t *testing.T) {
+	var msg proto.DependentColumnFilter
+	f := NewDependentColumnFilter([]byte("cf"), []byte("q"), true, NoOp, nil)
+	decodeFilter(t, f, "DependentColumnFilter", &msg)
+
+	if msg.GetCompareFilter().Comparator != nil {
+		t.Errorf("Comparator = %v, want none", msg.GetCompareFilter().Comparator)
+	}
+
+	if string(msg.GetColumnFamily()) != "cf" || string(msg.GetColumnQualifier()) != "q" || !msg.GetDropDependentColumn() {
+		t.Errorf("DependentColumnFilter = %v", &msg)
+	}
+}
+
+func TestSingleColumnValueFilterProto(t *testing.T) {
+	f := NewSingleColumnValueFilter([]byte("cf"), []byte("q"), Equal, NewBinaryComparator([]byte("v")))
+	f.SetFilterIfMissing(true)
+
+	var msg proto.SingleColumnValueFilter
+	decodeFilter(t, f, "SingleColumnValueFilter", &msg)
+
+	if string(msg.GetColumnFamily()) != "cf" || string(msg.GetColumnQualifier()) != "q" {
+		t.Errorf("column = %s:%s, want cf:q", msg.GetColumnFamily(), msg.GetColumnQualifier())
+	}
+
+	if !msg.GetFilterIfMissing() || !msg.GetLatestVersionOnly() {
+		t.Errorf("FilterIfMissing = %v, LatestVersionOnly = %v, want both set",
+			msg.GetFilterIfMissing(), msg.GetLatestVersionOnly())
+	}
+
+	if msg.GetCompareOp() != proto.CompareType_EQUAL {
+		t.Errorf("CompareOp = %v, want EQUAL", msg.GetCompareOp())
+	}
+}
+
+func TestSingleColumnValueExcludeFilterProto(t *testing.T) {
+	f := NewSingleColumnValueExcludeFilter([]byte("cf"), []byte("q"), NotEqual, nil)
+	f.SetLatestVersionOnly(false)
+
+	var msg proto.SingleColumnValueExcludeFilter
+	decodeFilter(t, f, "SingleColumnValueExcludeFilter", &msg)
+
+	scvf := msg.GetSingleColumnValueFilter()
+	if scvf == nil || string(scvf.GetColumnFamily()) != "cf" || string(scvf.GetColumnQualifier()) != "q" {
+		t.Fatalf("SingleColumnValueFilter = %v, want cf:q", scvf)
+	}
+
+	if scvf.GetLatestVersionOnly() {
+		t.Error("LatestVersionOnly = true, want false")
+	}
+
+	if scvf.Comparator != nil {
+		t.Errorf("Comparator = %v, want none", scvf.Comparator)
+	}
+}
+
+func TestFilterListProto(t *testing.T) {
+	inner := NewFilterList(MustPassOne, NewPrefixFilter([]byte("a")), NewPrefixFilter([]byte("b")))
+	outer := NewFilterList(MustPassAll, inner)
+	outer.AddFilter(NewFirstKeyOnlyFilter())
+
+	var msg proto.FilterList
+	decodeFilter(t, outer, "FilterList", &msg)
+
+	if msg.GetOperator() != proto.FilterList_MUST_PASS_ALL {
+		t.Errorf("Operator = %v, want MUST_PASS_ALL", msg.GetOperator())
+	}
+
+	filters := msg.GetFilters()
+	if len(filters) != 2 || filters[1].GetName() != filterPackage+"FirstKeyOnlyFilter" {
+		t.Fatalf("Filters = %v, want FilterList and FirstKeyOnlyFilter", filters)
+	}
+
+	var nested proto.FilterList
+	decodeSerialized(t, filters[0].GetName(), "FilterList", filters[0].GetSerializedFilter(), &nested)
+
+	if nested.GetOperator() != proto.FilterList_MUST_PASS_ONE || len(nested.GetFilters()) != 2 {
+		t.Errorf("nested FilterList = %v", &nested)
+	}
+}
+
+func TestTimestampsFilterSorted(t *testing.T) {
+	var msg proto.TimestampsFilter
+	decodeFilter(t, NewTimestampsFilter(30, 10, 20), "TimestampsFilter", &msg)
+
+	ts := msg.GetTimestamps()
+	if len(ts) != 3 || ts[0] != 10 || ts[1] != 20 || ts[2] != 30 {
+		t.Errorf("Timestamps = %v, want [10 20 30]", ts)
+	}
+}
+
+func TestMultipleColumnPrefixFilterSorted(t *testing.T) {
+	var msg proto.MultipleColumnPrefixFilter
+	f := NewMultipleColumnPrefixFilter([]byte("c"), []byte("a"), []byte("c"), []byte("b"))
+	decodeFilter(t, f, "MultipleColumnPrefixFilter", &msg)
+
+	prefixes := msg.GetSortedPrefixes()
+	if len(prefixes) != 3 || string(prefixes[0]) != "a" || string(prefixes[1]) != "b" || string(prefixes[2]) != "c" {
+		t.Errorf("SortedPrefixes = %q, want [a b c]", prefixes)
+	}
+}
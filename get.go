@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/cugbliwei/go-hbase/proto"
 	pb "github.com/golang/protobuf/proto"
@@ -14,6 +15,13 @@ type Get struct {
 	families   [][]byte
 	qualifiers [][][]byte
 	versions   int32
+	filter     Filter
+
+	timeRange   *TimeRange
+	storeLimit  uint32
+	storeOffset uint32
+
+	existenceOnly bool
 }
 
 func CreateNewGet(key []byte) *Get {
@@ -64,6 +72,38 @@ func (this *Get) AddFamily(family []byte) {
 	}
 }
 
+func (this *Get) SetFilter(filter Filter) {
+	this.filter = filter
+}
+
+// SetMaxVersions returns up to n versions of each column, newest first.
+func (this *Get) SetMaxVersions(n int32) {
+	this.versions = n
+}
+
+// SetTimeRange only returns versions with a timestamp in [from, to).
+func (this *Get) SetTimeRange(from, to time.Time) {
+	this.timeRange = &TimeRange{
+		From: from,
+		To:   to,
+	}
+}
+
+// SetTimestamp only returns the versions written at exactly ts.
+func (this *Get) SetTimestamp(ts time.Time) {
+	this.SetTimeRange(ts, ts.Add(time.Millisecond))
+}
+
+// SetStoreLimit returns at most limit cells per column family.
+func (this *Get) SetStoreLimit(limit uint32) {
+	this.storeLimit = limit
+}
+
+// SetStoreOffset skips the first offset cells of each column family.
+func (this *Get) SetStoreOffset(offset uint32) {
+	this.storeOffset = offset
+}
+
 func (this *Get) posOfFamily(family []byte) int {
 	for p, v := range this.families {
 		if bytes.Equal(family, v) {
@@ -87,5 +127,25 @@ func (this *Get) toProto() pb.Message {
 
 	g.MaxVersions = pb.Uint32(uint32(this.versions))
 
+	if this.filter != nil {
+		g.Filter = this.filter.toProto()
+	}
+
+	if this.timeRange != nil {
+		g.TimeRange = this.timeRange.toProto()
+	}
+
+	if this.storeLimit > 0 {
+		g.StoreLimit = pb.Uint32(this.storeLimit)
+	}
+
+	if this.storeOffset > 0 {
+		g.StoreOffset = pb.Uint32(this.storeOffset)
+	}
+
+	if this.existenceOnly {
+		g.ExistenceOnly = pb.Bool(true)
+	}
+
 	return g
 }
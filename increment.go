@@ -0,0 +1,95 @@
+package hbase
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/cugbliwei/go-hbase/proto"
+	pb "github.com/golang/protobuf/proto"
+)
+
+type Increment struct {
+	mutationOptions
+
+	key        []byte
+	families   [][]byte
+	qualifiers [][][]byte
+	amounts    [][]int64
+}
+
+func CreateNewIncrement(key []byte) *Increment {
+	return &Increment{
+		key:        key,
+		families:   make([][]byte, 0),
+		qualifiers: make([][][]byte, 0),
+		amounts:    make([][]int64, 0),
+	}
+}
+
+// AddValue increments the counter stored at family:column by amount.
+// The counter is stored as an 8 byte big-endian long, like Java's Bytes.toBytes(long).
+func (this *Increment) AddValue(family, column []byte, amount int64) {
+	pos := this.posOfFamily(family)
+
+	if pos == -1 {
+		this.families = append(this.families, family)
+		this.qualifiers = append(this.qualifiers, make([][]byte, 0))
+		this.amounts = append(this.amounts, make([]int64, 0))
+
+		pos = this.posOfFamily(family)
+	}
+
+	this.qualifiers[pos] = append(this.qualifiers[pos], column)
+	this.amounts[pos] = append(this.amounts[pos], amount)
+}
+
+func (this *Increment) AddStringValue(family, column string, amount int64) {
+	this.AddValue([]byte(family), []byte(column), amount)
+}
+
+// SetTTL expires the written cells after ttl, in millisecond precision.
+func (this *Increment) SetTTL(ttl time.Duration) {
+	this.setTTL(ttl)
+}
+
+func (this *Increment) posOfFamily(family []byte) int {
+	for p, v := range this.families {
+		if bytes.Equal(family, v) {
+			return p
+		}
+	}
+	return -1
+}
+
+func (this *Increment) row() []byte {
+	return this.key
+}
+
+func (this *Increment) toProto() pb.Message {
+	p := &proto.MutationProto{
+		Row:        this.key,
+		MutateType: proto.MutationProto_INCREMENT.Enum(),
+	}
+
+	for i, family := range this.families {
+		cv := &proto.MutationProto_ColumnValue{
+			Family: family,
+		}
+
+		for j, _ := range this.qualifiers[i] {
+			value := make([]byte, 8)
+			byte_order.PutUint64(value, uint64(this.amounts[i][j]))
+
+			cv.QualifierValue = append(cv.QualifierValue, &proto.MutationProto_ColumnValue_QualifierValue{
+				Qualifier: this.qualifiers[i][j],
+				Value:     value,
+			})
+		}
+
+		p.ColumnValue = append(p.ColumnValue, cv)
+	}
+
+	this.applyTo(p)
+
+	return p
+}
@@ -0,0 +1,575 @@
+package hbase
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Marshal and Unmarshal map struct fields to cells by their hbase tag:
+//
+//	Name   string            `hbase:"cf:name"`
+//	Age    int64             `hbase:"cf:age,codec=string"`
+//	Score  *float64          `hbase:"cf:score"`
+//	Labels map[string]string `hbase:"labels:"`
+//	Addr   Address
+//	Skip   string            `hbase:"-"`
+//
+// The codec defaults to the Java Bytes encoding of the field type:
+// string, bytes, int16, int32, int64 (also for int), float32, float64,
+// bool and bigdecimal. codec=string stores numbers and bools as text.
+// Nil pointers are not written, and stay nil when the cell is missing.
+// A family only tag on a map[string]T maps every qualifier of the family.
+// Untagged struct fields, and pointers to them, are descended into, also
+// when embedded unexported like encoding/json; other untagged fields are
+// ignored. A nil pointer to a struct is only allocated when one of its
+// columns is present. A struct nested in itself is only mapped once, at
+// the outermost level.
+
+const struct_tag = "hbase"
+
+var bigDecimalType = reflect.TypeOf(BigDecimal{})
+
+type fieldTag struct {
+	family    string
+	qualifier string
+	codec     string
+	// the tag names a whole family, for a map of qualifiers
+	isFamily bool
+}
+
+func parseFieldTag(tag string) (*fieldTag, error) {
+	parts := strings.Split(tag, ",")
+
+	column := strings.SplitN(parts[0], ":", 2)
+	ft := &fieldTag{
+		family: column[0],
+	}
+	if ft.family == "" {
+		return nil, fmt.Errorf("Missing family in hbase tag %q", tag)
+	}
+
+	if len(column) == 1 || column[1] == "" {
+		ft.isFamily = true
+	} else {
+		ft.qualifier = column[1]
+	}
+
+	for _, opt := range parts[1:] {
+		if strings.HasPrefix(opt, "codec=") {
+			ft.codec = strings.TrimPrefix(opt, "codec=")
+		} else {
+			return nil, fmt.Errorf("Unknown option %q in hbase tag %q", opt, tag)
+		}
+	}
+
+	return ft, nil
+}
+
+// Marshal returns a put of rowKey writing the tagged fields of v, a
+// struct or a pointer to one.
+func Marshal(rowKey []byte, v interface{}) (*Put, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, fmt.Errorf("Cannot marshal nil %T", v)
+		}
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("Cannot marshal %T, a struct is needed", v)
+	}
+
+	put := CreateNewPut(rowKey)
+	if err := marshalStruct(put, rv, make(map[reflect.Type]bool)); err != nil {
+		return nil, err
+	}
+
+	return put, nil
+}
+
+// marshalStruct adds the fields of rv to put, visiting holds the struct
+// types being marshaled.
+func marshalStruct(put *Put, rv reflect.Value, visiting map[reflect.Type]bool) error {
+	t := rv.Type()
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+
+		fv := rv.Field(i)
+		tag, ok := f.Tag.Lookup(struct_tag)
+
+		if !ok {
+			st := nestedStruct(f.Type)
+			if st == nil || visiting[st] {
+				continue
+			}
+
+			if fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+
+			if err := marshalStruct(put, fv, visiting); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if tag == "-" || f.PkgPath != "" {
+			continue
+		}
+
+		ft, err := parseFieldTag(tag)
+		if err != nil {
+			return fmt.Errorf("Field %s: %v", f.Name, err)
+		}
+
+		if ft.isFamily {
+			if fv.Kind() != reflect.Map || fv.Type().Key().Kind() != reflect.String {
+				return fmt.Errorf("Field %s: a family tag needs a map[string]T", f.Name)
+			}
+
+			for _, k := range fv.MapKeys() {
+				b, err := encodeField(fv.MapIndex(k), ft.codec)
+				if err != nil {
+					return fmt.Errorf("Field %s[%s]: %v", f.Name, k.String(), err)
+				}
+				if b != nil {
+					put.AddValue([]byte(ft.family), []byte(k.String()), b)
+				}
+			}
+			continue
+		}
+
+		b, err := encodeField(fv, ft.codec)
+		if err != nil {
+			return fmt.Errorf("Field %s: %v", f.Name, err)
+		}
+		if b != nil {
+			put.AddValue([]byte(ft.family), []byte(ft.qualifier), b)
+		}
+	}
+
+	return nil
+}
+
+// Unmarshal sets the tagged fields of v, a pointer to a struct, from
+// the latest values of row.
+func Unmarshal(row *ResultRow, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("Cannot unmarshal into %T, a non nil pointer is needed", v)
+	}
+
+	rv = rv.Elem()
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("Cannot unmarshal into %T, a struct is needed", v)
+	}
+
+	_, err := unmarshalStruct(row, rv, make(map[reflect.Type]bool))
+	return err
+}
+
+// unmarshalStruct sets the fields of rv from row and tells whether any
+// column was present, visiting holds the struct types being unmarshaled.
+func unmarshalStruct(row *ResultRow, rv reflect.Value, visiting map[reflect.Type]bool) (bool, error) {
+	t := rv.Type()
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	found := false
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+
+		fv := rv.Field(i)
+		tag, ok := f.Tag.Lookup(struct_tag)
+
+		if !ok {
+			st := nestedStruct(f.Type)
+			if st == nil || visiting[st] {
+				continue
+			}
+
+			if fv.Kind() != reflect.Ptr {
+				ok, err := unmarshalStruct(row, fv, visiting)
+				if err != nil {
+					return false, err
+				}
+				found = found || ok
+				continue
+			}
+
+			// fill a new struct, kept only if one of its columns is present
+			nv := fv
+			if fv.IsNil() {
+				if !fv.CanSet() {
+					// embedded pointer to an unexported struct
+					continue
+				}
+				nv = reflect.New(st)
+			}
+
+			ok, err := unmarshalStruct(row, nv.Elem(), visiting)
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				// a struct which was already set was filled in place
+				if fv.IsNil() {
+					fv.Set(nv)
+				}
+				found = true
+			}
+			continue
+		}
+
+		if tag == "-" || f.PkgPath != "" {
+			continue
+		}
+
+		ft, err := parseFieldTag(tag)
+		if err != nil {
+			return false, fmt.Errorf("Field %s: %v", f.Name, err)
+		}
+
+		if ft.isFamily {
+			if fv.Kind() != reflect.Map || fv.Type().Key().Kind() != reflect.String {
+				return false, fmt.Errorf("Field %s: a family tag needs a map[string]T", f.Name)
+			}
+
+			for _, col := range row.SortedColumns {
+				if col.Family.String() != ft.family {
+					continue
+				}
+
+				if fv.IsNil() {
+					fv.Set(reflect.MakeMap(fv.Type()))
+				}
+
+				ev := reflect.New(fv.Type().Elem()).Elem()
+				if err := decodeField(col.Value, ev, ft.codec); err != nil {
+					return false, fmt.Errorf("Field %s[%s]: %v", f.Name, col.Qualifier.String(), err)
+				}
+
+				fv.SetMapIndex(reflect.ValueOf(col.Qualifier.String()).Convert(fv.Type().Key()), ev)
+				found = true
+			}
+			continue
+		}
+
+		col, ok := row.Columns[ft.family+":"+ft.qualifier]
+		if !ok {
+			continue
+		}
+
+		if err := decodeField(col.Value, fv, ft.codec); err != nil {
+			return false, fmt.Errorf("Field %s: %v", f.Name, err)
+		}
+		found = true
+	}
+
+	return found, nil
+}
+
+// nestedStruct returns the struct type an untagged field of type t is
+// descended into, nil if it isn't.
+func nestedStruct(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct || t == bigDecimalType {
+		return nil
+	}
+
+	return t
+}
+
+// defaultCodec is the Java Bytes encoding of t.
+func defaultCodec(t reflect.Type) string {
+	if t == bigDecimalType {
+		return "bigdecimal"
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "bytes"
+		}
+	case reflect.Int16:
+		return "int16"
+	case reflect.Int32:
+		return "int32"
+	case reflect.Int, reflect.Int64:
+		return "int64"
+	case reflect.Float32:
+		return "float32"
+	case reflect.Float64:
+		return "float64"
+	case reflect.Bool:
+		return "bool"
+	}
+
+	return ""
+}
+
+// encodeField encodes v with codec, nil for a nil pointer.
+func encodeField(v reflect.Value, codec string) ([]byte, error) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+
+	if codec == "" {
+		codec = defaultCodec(v.Type())
+		if codec == "" {
+			return nil, fmt.Errorf("No default codec for %s", v.Type())
+		}
+	}
+
+	switch codec {
+	case "string":
+		switch v.Kind() {
+		case reflect.String:
+			return []byte(v.String()), nil
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return []byte(strconv.FormatInt(v.Int(), 10)), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return []byte(strconv.FormatUint(v.Uint(), 10)), nil
+		case reflect.Float32:
+			return []byte(strconv.FormatFloat(v.Float(), 'g', -1, 32)), nil
+		case reflect.Float64:
+			return []byte(strconv.FormatFloat(v.Float(), 'g', -1, 64)), nil
+		case reflect.Bool:
+			return []byte(strconv.FormatBool(v.Bool())), nil
+		}
+		if v.Type() == bigDecimalType {
+			d := v.Interface().(BigDecimal)
+			return []byte(d.String()), nil
+		}
+	case "bytes":
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Bytes(), nil
+		}
+		if v.Kind() == reflect.String {
+			return []byte(v.String()), nil
+		}
+	case "int16":
+		if isInt(v) {
+			if n := v.Int(); n != int64(int16(n)) {
+				return nil, fmt.Errorf("Value %d overflows int16", n)
+			}
+			return EncodeInt16(int16(v.Int())), nil
+		}
+	case "int32":
+		if isInt(v) {
+			if n := v.Int(); n != int64(int32(n)) {
+				return nil, fmt.Errorf("Value %d overflows int32", n)
+			}
+			return EncodeInt32(int32(v.Int())), nil
+		}
+	case "int64":
+		if isInt(v) {
+			return EncodeInt64(v.Int()), nil
+		}
+	case "float32":
+		if isFloat(v) {
+			return EncodeFloat32(float32(v.Float())), nil
+		}
+	case "float64":
+		if isFloat(v) {
+			return EncodeFloat64(v.Float()), nil
+		}
+	case "bool":
+		if v.Kind() == reflect.Bool {
+			return EncodeBool(v.Bool()), nil
+		}
+	case "bigdecimal":
+		if v.Type() == bigDecimalType {
+			d := v.Interface().(BigDecimal)
+			return EncodeBigDecimal(&d), nil
+		}
+	default:
+		return nil, fmt.Errorf("Unknown codec %q", codec)
+	}
+
+	return nil, fmt.Errorf("Codec %q cannot encode %s", codec, v.Type())
+}
+
+// decodeField decodes b with codec into v, allocating a pointer.
+func decodeField(b []byte, v reflect.Value, codec string) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+
+	if codec == "" {
+		codec = defaultCodec(v.Type())
+		if codec == "" {
+			return fmt.Errorf("No default codec for %s", v.Type())
+		}
+	}
+
+	switch codec {
+	case "string":
+		s := string(b)
+		switch v.Kind() {
+		case reflect.String:
+			v.SetString(s)
+			return nil
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+			if err != nil {
+				return err
+			}
+			v.SetInt(n)
+			return nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+			if err != nil {
+				return err
+			}
+			v.SetUint(n)
+			return nil
+		case reflect.Float32, reflect.Float64:
+			f, err := strconv.ParseFloat(s, v.Type().Bits())
+			if err != nil {
+				return err
+			}
+			v.SetFloat(f)
+			return nil
+		case reflect.Bool:
+			x, err := strconv.ParseBool(s)
+			if err != nil {
+				return err
+			}
+			v.SetBool(x)
+			return nil
+		}
+		if v.Type() == bigDecimalType {
+			d, err := ParseBigDecimal(s)
+			if err != nil {
+				return err
+			}
+			v.Set(reflect.ValueOf(*d))
+			return nil
+		}
+	case "bytes":
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			v.SetBytes(append([]byte{}, b...))
+			return nil
+		}
+		if v.Kind() == reflect.String {
+			v.SetString(string(b))
+			return nil
+		}
+	case "int16":
+		if isInt(v) {
+			n, err := DecodeInt16(b)
+			if err != nil {
+				return err
+			}
+			return setInt(v, int64(n))
+		}
+	case "int32":
+		if isInt(v) {
+			n, err := DecodeInt32(b)
+			if err != nil {
+				return err
+			}
+			return setInt(v, int64(n))
+		}
+	case "int64":
+		if isInt(v) {
+			n, err := DecodeInt64(b)
+			if err != nil {
+				return err
+			}
+			return setInt(v, n)
+		}
+	case "float32":
+		if isFloat(v) {
+			f, err := DecodeFloat32(b)
+			if err != nil {
+				return err
+			}
+			v.SetFloat(float64(f))
+			return nil
+		}
+	case "float64":
+		if isFloat(v) {
+			f, err := DecodeFloat64(b)
+			if err != nil {
+				return err
+			}
+			if v.OverflowFloat(f) {
+				return fmt.Errorf("Value %v overflows %s", f, v.Type())
+			}
+			v.SetFloat(f)
+			return nil
+		}
+	case "bool":
+		if v.Kind() == reflect.Bool {
+			x, err := DecodeBool(b)
+			if err != nil {
+				return err
+			}
+			v.SetBool(x)
+			return nil
+		}
+	case "bigdecimal":
+		if v.Type() == bigDecimalType {
+			d, err := DecodeBigDecimal(b)
+			if err != nil {
+				return err
+			}
+			v.Set(reflect.ValueOf(*d))
+			return nil
+		}
+	default:
+		return fmt.Errorf("Unknown codec %q", codec)
+	}
+
+	return fmt.Errorf("Codec %q cannot decode into %s", codec, v.Type())
+}
+
+func isInt(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	}
+	return false
+}
+
+func isFloat(v reflect.Value) bool {
+	return v.Kind() == reflect.Float32 || v.Kind() == reflect.Float64
+}
+
+func setInt(v reflect.Value, n int64) error {
+	if v.OverflowInt(n) {
+		return fmt.Errorf("Value %d overflows %s", n, v.Type())
+	}
+
+	v.SetInt(n)
+	return nil
+}
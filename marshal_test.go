@@ -0,0 +1,191 @@
+package hbase
+
+import (
+	"testing"
+)
+
+type testAddress struct {
+	City string `hbase:"addr:city"`
+	Zip  int32  `hbase:"addr:zip"`
+}
+
+type testContact struct {
+	Email string `hbase:"contact:email"`
+}
+
+type testTimestamps struct {
+	Created int64 `hbase:"meta:created"`
+}
+
+type testUser struct {
+	*testTimestamps
+
+	Name    string            `hbase:"cf:name"`
+	Age     int64             `hbase:"cf:age,codec=string"`
+	Score   *float64          `hbase:"cf:score"`
+	Labels  map[string]string `hbase:"labels:"`
+	Addr    testAddress
+	Contact *testContact
+	Skip    string `hbase:"-"`
+}
+
+// putRow returns the row the server would send back after put.
+func putRow(put *Put) *ResultRow {
+	cells := make([]Cell, 0)
+	for i, family := range put.families {
+		for j, qualifier := range put.qualifiers[i] {
+			cells = append(cells, newTestCell(string(put.key), string(family), string(qualifier), 1, CellPut, string(put.values[i][j])))
+		}
+	}
+
+	return newTestResultRow(cells...)
+}
+
+func TestMarshalColumns(t *testing.T) {
+	score := 1.5
+	u := &testUser{
+		testTimestamps: &testTimestamps{Created: 42},
+		Name:           "bob",
+		Age:            30,
+		Score:          &score,
+		Labels:         map[string]string{"a": "x", "b": "y"},
+		Addr:           testAddress{City: "paris", Zip: 75001},
+		Skip:           "skipped",
+	}
+
+	put, err := Marshal([]byte("row"), u)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	row := putRow(put)
+
+	tests := []struct {
+		column string
+		want   string
+	}{
+		{"cf:name", "bob"},
+		{"cf:age", "30"},
+		{"cf:score", string(EncodeFloat64(1.5))},
+		{"labels:a", "x"},
+		{"labels:b", "y"},
+		{"addr:city", "paris"},
+		{"addr:zip", string(EncodeInt32(75001))},
+		{"meta:created", string(EncodeInt64(42))},
+	}
+
+	for _, tt := range tests {
+		col, ok := row.Columns[tt.column]
+		if !ok {
+			t.Errorf("missing column %s", tt.column)
+			continue
+		}
+
+		if col.Value.String() != tt.want {
+			t.Errorf("%s = %q, want %q", tt.column, col.Value, tt.want)
+		}
+	}
+
+	// the nil Contact and the skipped field are not written
+	if len(row.Columns) != len(tests) {
+		t.Errorf("Marshal wrote %d columns, want %d", len(row.Columns), len(tests))
+	}
+}
+
+func TestUnmarshalRoundTrip(t *testing.T) {
+	score := -2.25
+	u := &testUser{
+		testTimestamps: &testTimestamps{Created: 7},
+		Name:           "alice",
+		Age:            41,
+		Score:          &score,
+		Labels:         map[string]string{"k": "v"},
+		Addr:           testAddress{City: "lyon", Zip: 69001},
+		Contact:        &testContact{Email: "a@b.c"},
+	}
+
+	put, err := Marshal([]byte("row"), u)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := &testUser{}
+	if err := Unmarshal(putRow(put), got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Name != "alice" || got.Age != 41 || got.Score == nil || *got.Score != -2.25 {
+		t.Errorf("Unmarshal = %+v", got)
+	}
+
+	if len(got.Labels) != 1 || got.Labels["k"] != "v" {
+		t.Errorf("Labels = %v, want map[k:v]", got.Labels)
+	}
+
+	if got.Addr != u.Addr {
+		t.Errorf("Addr = %+v, want %+v", got.Addr, u.Addr)
+	}
+
+	if got.Contact == nil || got.Contact.Email != "a@b.c" {
+		t.Errorf("Contact = %+v, want a@b.c", got.Contact)
+	}
+
+	// a nil embedded pointer to an unexported struct cannot be allocated
+	if got.testTimestamps != nil {
+		t.Errorf("testTimestamps = %+v, want nil", got.testTimestamps)
+	}
+}
+
+func TestUnmarshalMissingColumns(t *testing.T) {
+	row := newTestResultRow(newTestCell("row", "cf", "name", 1, CellPut, "carol"))
+
+	got := &testUser{}
+	if err := Unmarshal(row, got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Name != "carol" {
+		t.Errorf("Name = %q, want carol", got.Name)
+	}
+
+	// pointers stay nil when none of their columns are present
+	if got.Score != nil || got.Contact != nil || got.Labels != nil {
+		t.Errorf("Score = %v, Contact = %v, Labels = %v, want nil", got.Score, got.Contact, got.Labels)
+	}
+}
+
+func TestUnmarshalEmbeddedPointerSet(t *testing.T) {
+	row := newTestResultRow(newTestCell("row", "meta", "created", 1, CellPut, string(EncodeInt64(99))))
+
+	ts := &testTimestamps{}
+	got := &testUser{testTimestamps: ts}
+	if err := Unmarshal(row, got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.testTimestamps != ts || ts.Created != 99 {
+		t.Errorf("testTimestamps = %+v, want Created 99 filled in place", got.testTimestamps)
+	}
+}
+
+func TestMarshalErrors(t *testing.T) {
+	var nilUser *testUser
+	if _, err := Marshal([]byte("row"), nilUser); err == nil {
+		t.Error("Marshal(nil) succeeded")
+	}
+
+	if _, err := Marshal([]byte("row"), 1); err == nil {
+		t.Error("Marshal(int) succeeded")
+	}
+
+	type badFamily struct {
+		Tags []string `hbase:"tags:"`
+	}
+	if _, err := Marshal([]byte("row"), badFamily{Tags: []string{"a"}}); err == nil {
+		t.Error("Marshal of a family tag on a slice succeeded")
+	}
+
+	if err := Unmarshal(&ResultRow{}, testUser{}); err == nil {
+		t.Error("Unmarshal into a struct value succeeded")
+	}
+}
@@ -0,0 +1,74 @@
+package hbase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cugbliwei/go-hbase/proto"
+)
+
+const multi_row_mutation_service = "MultiRowMutationService"
+
+// MutateRows applies muts atomically through the MultiRowMutationEndpoint
+// coprocessor, which has to be loaded for table. Every row has to be in
+// the same region, e.g. an index row and the data row it points to.
+// Only puts and deletes are supported by the endpoint.
+func (c *Client) MutateRows(table string, muts []Mutation) error {
+	return c.MutateRowsContext(context.Background(), table, muts)
+}
+
+func (c *Client) MutateRowsContext(ctx context.Context, table string, muts []Mutation) error {
+	if len(muts) == 0 {
+		return nil
+	}
+
+	req := &proto.MutateRowsRequest{
+		MutationRequest: make([]*proto.MutationProto, len(muts)),
+	}
+
+	for i, m := range muts {
+		switch m.(type) {
+		case *Put, *Delete:
+		default:
+			return fmt.Errorf("Unsupported mutation for MutateRows: %T", m)
+		}
+
+		req.MutationRequest[i] = m.toProto().(*proto.MutationProto)
+	}
+
+	row := muts[0].row()
+	if err := c.checkSameRegion(ctx, ParseTableName(table), muts); err != nil {
+		return err
+	}
+
+	call, err := newCoprocessorCall(multi_row_mutation_service, "MutateRows", req)
+	if err != nil {
+		return err
+	}
+
+	ch := c.action(ctx, ParseTableName(table), row, call, true, 0)
+
+	return coprocessorResponse(ctx, <-ch, &proto.MutateRowsResponse{})
+}
+
+// checkSameRegion fails unless the rows of muts all map to one region.
+func (c *Client) checkSameRegion(ctx context.Context, table TableName, muts []Mutation) error {
+	first, err := c.locateRegion(ctx, table, muts[0].row(), true)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range muts[1:] {
+		region, err := c.locateRegion(ctx, table, m.row(), true)
+		if err != nil {
+			return err
+		}
+
+		if region.name != first.name {
+			return fmt.Errorf("Rows are not in the same region [row: %q] [region: %s] [row: %q] [region: %s]",
+				muts[0].row(), first.name, m.row(), region.name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package hbase
+
+import (
+	"time"
+
+	"github.com/cugbliwei/go-hbase/proto"
+	pb "github.com/golang/protobuf/proto"
+)
+
+type Durability int32
+
+const (
+	UseDefault Durability = Durability(proto.MutationProto_USE_DEFAULT)
+	SkipWal    Durability = Durability(proto.MutationProto_SKIP_WAL)
+	AsyncWal   Durability = Durability(proto.MutationProto_ASYNC_WAL)
+	SyncWal    Durability = Durability(proto.MutationProto_SYNC_WAL)
+	FsyncWal   Durability = Durability(proto.MutationProto_FSYNC_WAL)
+)
+
+// Mutation is a *Put, *Delete, *Increment or *Append.
+type Mutation interface {
+	action
+	row() []byte
+}
+
+// attribute names understood by the region server, see Java's Mutation
+const (
+	ttl_attribute        = "_ttl"
+	visibility_attribute = "VISIBILITY"
+)
+
+// mutationOptions holds the settings shared by every mutation type.
+type mutationOptions struct {
+	durability Durability
+	attributes []*proto.NameBytesPair
+}
+
+// SetDurability sets how the mutation is written to the WAL.
+// SkipWal and AsyncWal trade durability for throughput.
+func (this *mutationOptions) SetDurability(d Durability) {
+	this.durability = d
+}
+
+// SetAttribute sets an arbitrary attribute, replacing any previous value for name.
+func (this *mutationOptions) SetAttribute(name string, value []byte) {
+	for _, a := range this.attributes {
+		if a.GetName() == name {
+			a.Value = value
+			return
+		}
+	}
+
+	this.attributes = append(this.attributes, &proto.NameBytesPair{
+		Name:  pb.String(name),
+		Value: value,
+	})
+}
+
+// SetCellVisibility sets the visibility expression of the written cells, e.g. "secret|(public&!internal)".
+func (this *mutationOptions) SetCellVisibility(expression string) {
+	vis, _ := pb.Marshal(&proto.CellVisibility{
+		Expression: pb.String(expression),
+	})
+
+	this.SetAttribute(visibility_attribute, vis)
+}
+
+func (this *mutationOptions) setTTL(ttl time.Duration) {
+	b := make([]byte, 8)
+	byte_order.PutUint64(b, uint64(ttl/time.Millisecond))
+
+	this.SetAttribute(ttl_attribute, b)
+}
+
+func (this *mutationOptions) applyTo(p *proto.MutationProto) {
+	if this.durability != UseDefault {
+		p.Durability = proto.MutationProto_Durability(this.durability).Enum()
+	}
+
+	p.Attribute = this.attributes
+}
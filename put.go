@@ -2,12 +2,15 @@ package hbase
 
 import (
 	"bytes"
+	"time"
 
 	"github.com/cugbliwei/go-hbase/proto"
 	pb "github.com/golang/protobuf/proto"
 )
 
 type Put struct {
+	mutationOptions
+
 	key        []byte
 	families   [][]byte
 	qualifiers [][][]byte
@@ -56,6 +59,47 @@ func (this *Put) AddStringValueTS(family, column, value string, ts int64) {
 	this.AddValueTS([]byte(family), []byte(column), []byte(value), ts)
 }
 
+// AddInt16Value writes value like Java's Bytes.toBytes(short).
+func (this *Put) AddInt16Value(family, column []byte, value int16) {
+	this.AddValue(family, column, EncodeInt16(value))
+}
+
+// AddInt32Value writes value like Java's Bytes.toBytes(int).
+func (this *Put) AddInt32Value(family, column []byte, value int32) {
+	this.AddValue(family, column, EncodeInt32(value))
+}
+
+// AddInt64Value writes value like Java's Bytes.toBytes(long), which
+// increments can add to.
+func (this *Put) AddInt64Value(family, column []byte, value int64) {
+	this.AddValue(family, column, EncodeInt64(value))
+}
+
+// AddFloat32Value writes value like Java's Bytes.toBytes(float).
+func (this *Put) AddFloat32Value(family, column []byte, value float32) {
+	this.AddValue(family, column, EncodeFloat32(value))
+}
+
+// AddFloat64Value writes value like Java's Bytes.toBytes(double).
+func (this *Put) AddFloat64Value(family, column []byte, value float64) {
+	this.AddValue(family, column, EncodeFloat64(value))
+}
+
+// AddBoolValue writes value like Java's Bytes.toBytes(boolean).
+func (this *Put) AddBoolValue(family, column []byte, value bool) {
+	this.AddValue(family, column, EncodeBool(value))
+}
+
+// AddBigDecimalValue writes value like Java's Bytes.toBytes(BigDecimal).
+func (this *Put) AddBigDecimalValue(family, column []byte, value *BigDecimal) {
+	this.AddValue(family, column, EncodeBigDecimal(value))
+}
+
+// SetTTL expires the written cells after ttl, in millisecond precision.
+func (this *Put) SetTTL(ttl time.Duration) {
+	this.setTTL(ttl)
+}
+
 func (this *Put) posOfFamily(family []byte) int {
 	for p, v := range this.families {
 		if bytes.Equal(family, v) {
@@ -65,6 +109,10 @@ func (this *Put) posOfFamily(family []byte) int {
 	return -1
 }
 
+func (this *Put) row() []byte {
+	return this.key
+}
+
 func (this *Put) toProto() pb.Message {
 	p := &proto.MutationProto{
 		Row:        this.key,
@@ -90,5 +138,7 @@ func (this *Put) toProto() pb.Message {
 		p.ColumnValue = append(p.ColumnValue, cv)
 	}
 
+	this.applyTo(p)
+
 	return p
 }
@@ -2,6 +2,7 @@ package hbase
 
 import (
 	"bytes"
+	"sort"
 	"time"
 
 	"github.com/cugbliwei/go-hbase/proto"
@@ -11,6 +12,9 @@ type ResultRow struct {
 	Row           EncodedValue
 	Columns       map[string]*ResultRowColumn
 	SortedColumns []*ResultRowColumn
+
+	// every cell of the row, in HBase order
+	Cells []Cell
 }
 
 type ResultRowColumn struct {
@@ -23,24 +27,41 @@ type ResultRowColumn struct {
 	Value     EncodedValue
 
 	Values map[time.Time]EncodedValue
+
+	// the versions of Values, newest first
+	versions []*ResultRowValue
+}
+
+type ResultRowValue struct {
+	Timestamp time.Time
+	Value     EncodedValue
 }
 
 func newResultRow(result *proto.Result) *ResultRow {
 	res := &ResultRow{}
 	res.Columns = make(map[string]*ResultRowColumn)
 	res.SortedColumns = make([]*ResultRowColumn, 0)
+	res.Cells = make([]Cell, len(result.GetCell()))
 
-	for _, cell := range result.GetCell() {
-		res.Row = cell.GetRow()
+	for i, cell := range result.GetCell() {
+		res.Cells[i] = CellFromProto(cell)
+	}
+
+	sort.SliceStable(res.Cells, func(i, j int) bool {
+		return compareCells(&res.Cells[i], &res.Cells[j]) < 0
+	})
+
+	for _, cell := range res.Cells {
+		res.Row = cell.Row
 
 		col := ResultRowColumn{
-			Family:    cell.GetFamily(),
-			Qualifier: cell.GetQualifier(),
-			Value:     cell.GetValue(),
-			Timestamp: time.Unix(int64(cell.GetTimestamp()), 0),
+			Family:    cell.Family,
+			Qualifier: cell.Qualifier,
+			Value:     cell.Value,
+			Timestamp: cell.Time(),
 		}
 
-		col.ColumnName = col.Family.String() + ":" + col.Qualifier.String()
+		col.ColumnName = cell.ColumnName()
 
 		if v, exists := res.Columns[col.ColumnName]; exists {
 
@@ -49,10 +70,11 @@ func newResultRow(result *proto.Result) *ResultRow {
 				v.Timestamp = col.Timestamp
 			}
 
-			v.Values[col.Timestamp] = col.Value
+			v.addValue(col.Timestamp, col.Value)
 
 		} else {
 			col.Values = map[time.Time]EncodedValue{col.Timestamp: col.Value}
+			col.versions = []*ResultRowValue{&ResultRowValue{Timestamp: col.Timestamp, Value: col.Value}}
 
 			res.Columns[col.ColumnName] = &col
 			res.SortedColumns = append(res.SortedColumns, &col)
@@ -63,8 +85,103 @@ func newResultRow(result *proto.Result) *ResultRow {
 	return res
 }
 
+// Latest returns the newest cell of family:qualifier, nil if there is none.
+func (r *ResultRow) Latest(family, qualifier []byte) *Cell {
+	versions := r.Versions(family, qualifier)
+	if len(versions) == 0 {
+		return nil
+	}
+
+	return &versions[0]
+}
+
+// Versions returns the cells of family:qualifier, newest first.
+func (r *ResultRow) Versions(family, qualifier []byte) []Cell {
+	i := sort.Search(len(r.Cells), func(i int) bool {
+		c := &r.Cells[i]
+		if f := bytes.Compare(c.Family, family); f != 0 {
+			return f > 0
+		}
+		return bytes.Compare(c.Qualifier, qualifier) >= 0
+	})
+
+	j := i
+	for j < len(r.Cells) && bytes.Equal(r.Cells[j].Family, family) && bytes.Equal(r.Cells[j].Qualifier, qualifier) {
+		j++
+	}
+
+	return r.Cells[i:j]
+}
+
+// Family returns the cells of family, ordered by qualifier, newest first.
+func (r *ResultRow) Family(family []byte) []Cell {
+	i := sort.Search(len(r.Cells), func(i int) bool {
+		return bytes.Compare(r.Cells[i].Family, family) >= 0
+	})
+
+	j := i
+	for j < len(r.Cells) && bytes.Equal(r.Cells[j].Family, family) {
+		j++
+	}
+
+	return r.Cells[i:j]
+}
+
+// Versions returns the versions returned by the server, newest first.
+func (c *ResultRowColumn) Versions() []*ResultRowValue {
+	return c.versions
+}
+
+func (c *ResultRowColumn) addValue(ts time.Time, value EncodedValue) {
+	c.Values[ts] = value
+
+	i := sort.Search(len(c.versions), func(i int) bool {
+		return c.versions[i].Timestamp.Before(ts)
+	})
+
+	c.versions = append(c.versions, nil)
+	copy(c.versions[i+1:], c.versions[i:])
+	c.versions[i] = &ResultRowValue{Timestamp: ts, Value: value}
+}
+
 type EncodedValue []byte
 
 func (e EncodedValue) String() string {
 	return bytes.NewBuffer(e).String()
 }
+
+// Int16 decodes Java's Bytes.toBytes(short).
+func (e EncodedValue) Int16() (int16, error) {
+	return DecodeInt16(e)
+}
+
+// Int32 decodes Java's Bytes.toBytes(int).
+func (e EncodedValue) Int32() (int32, error) {
+	return DecodeInt32(e)
+}
+
+// Int64 decodes an 8 byte big-endian long, as written by increments or
+// Java's Bytes.toBytes(long).
+func (e EncodedValue) Int64() (int64, error) {
+	return DecodeInt64(e)
+}
+
+// Float32 decodes Java's Bytes.toBytes(float).
+func (e EncodedValue) Float32() (float32, error) {
+	return DecodeFloat32(e)
+}
+
+// Float64 decodes Java's Bytes.toBytes(double).
+func (e EncodedValue) Float64() (float64, error) {
+	return DecodeFloat64(e)
+}
+
+// Bool decodes Java's Bytes.toBytes(boolean).
+func (e EncodedValue) Bool() (bool, error) {
+	return DecodeBool(e)
+}
+
+// BigDecimal decodes Java's Bytes.toBytes(BigDecimal).
+func (e EncodedValue) BigDecimal() (*BigDecimal, error) {
+	return DecodeBigDecimal(e)
+}
@@ -0,0 +1,139 @@
+package hbase
+
+import (
+	"testing"
+)
+
+func testRow() *ResultRow {
+	// sent out of order, newResultRow sorts them
+	return newTestResultRow(
+		newTestCell("r", "b", "x", 1, CellPut, "bx1"),
+		newTestCell("r", "a", "y", 5, CellPut, "ay5"),
+		newTestCell("r", "a", "x", 2, CellPut, "ax2"),
+		newTestCell("r", "c", "x", 1, CellPut, "cx1"),
+		newTestCell("r", "a", "x", 7, CellPut, "ax7"),
+		newTestCell("r", "a", "x", 4, CellPut, "ax4"),
+		newTestCell("r", "a", "xx", 3, CellPut, "axx3"),
+	)
+}
+
+func cellValues(cells []Cell) []string {
+	values := make([]string, len(cells))
+	for i := range cells {
+		values[i] = cells[i].Value.String()
+	}
+
+	return values
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestResultRowCellsSorted(t *testing.T) {
+	r := testRow()
+
+	want := []string{"ax7", "ax4", "ax2", "axx3", "ay5", "bx1", "cx1"}
+	if got := cellValues(r.Cells); !equalStrings(got, want) {
+		t.Errorf("Cells = %v, want %v", got, want)
+	}
+
+	if r.Row.String() != "r" {
+		t.Errorf("Row = %q, want %q", r.Row, "r")
+	}
+}
+
+func TestResultRowVersions(t *testing.T) {
+	r := testRow()
+
+	tests := []struct {
+		family    string
+		qualifier string
+		want      []string
+	}{
+		{"a", "x", []string{"ax7", "ax4", "ax2"}},
+		{"a", "xx", []string{"axx3"}},
+		{"a", "y", []string{"ay5"}},
+		{"c", "x", []string{"cx1"}},
+		// missing columns, before, between and after the cells
+		{"a", "", []string{}},
+		{"a", "w", []string{}},
+		{"a", "z", []string{}},
+		{"b", "y", []string{}},
+		{"d", "x", []string{}},
+	}
+
+	for _, tt := range tests {
+		got := cellValues(r.Versions([]byte(tt.family), []byte(tt.qualifier)))
+		if !equalStrings(got, tt.want) {
+			t.Errorf("Versions(%s, %s) = %v, want %v", tt.family, tt.qualifier, got, tt.want)
+		}
+	}
+}
+
+func TestResultRowLatest(t *testing.T) {
+	r := testRow()
+
+	if c := r.Latest([]byte("a"), []byte("x")); c == nil || c.Value.String() != "ax7" {
+		t.Errorf("Latest(a, x) = %v, want ax7", c)
+	}
+
+	if c := r.Latest([]byte("a"), []byte("w")); c != nil {
+		t.Errorf("Latest(a, w) = %v, want nil", c)
+	}
+}
+
+func TestResultRowFamily(t *testing.T) {
+	r := testRow()
+
+	tests := []struct {
+		family string
+		want   []string
+	}{
+		{"a", []string{"ax7", "ax4", "ax2", "axx3", "ay5"}},
+		{"b", []string{"bx1"}},
+		{"c", []string{"cx1"}},
+		{"", []string{}},
+		{"aa", []string{}},
+		{"d", []string{}},
+	}
+
+	for _, tt := range tests {
+		got := cellValues(r.Family([]byte(tt.family)))
+		if !equalStrings(got, tt.want) {
+			t.Errorf("Family(%s) = %v, want %v", tt.family, got, tt.want)
+		}
+	}
+}
+
+func TestResultRowColumns(t *testing.T) {
+	r := testRow()
+
+	col, ok := r.Columns["a:x"]
+	if !ok {
+		t.Fatal("missing column a:x")
+	}
+
+	if col.Value.String() != "ax7" || col.Timestamp.UnixNano() != 7*1e6 {
+		t.Errorf("a:x = %s at %v, want ax7 at 7ms", col.Value, col.Timestamp)
+	}
+
+	if len(col.Values) != 3 || col.Values[msToTime(4)].String() != "ax4" {
+		t.Errorf("a:x Values = %v", col.Values)
+	}
+
+	versions := col.Versions()
+	if len(versions) != 3 || versions[0].Value.String() != "ax7" || versions[2].Value.String() != "ax2" {
+		t.Errorf("a:x Versions = %v", versions)
+	}
+}
@@ -0,0 +1,80 @@
+package hbase
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/cugbliwei/go-hbase/proto"
+	pb "github.com/golang/protobuf/proto"
+)
+
+// RowMutations combines puts and deletes of one row, which are applied
+// atomically and in order.
+type RowMutations struct {
+	key       []byte
+	mutations []Mutation
+}
+
+func CreateNewRowMutations(key []byte) *RowMutations {
+	return &RowMutations{
+		key:       key,
+		mutations: make([]Mutation, 0),
+	}
+}
+
+func (this *RowMutations) AddPut(put *Put) error {
+	return this.add(put)
+}
+
+func (this *RowMutations) AddDelete(del *Delete) error {
+	return this.add(del)
+}
+
+func (this *RowMutations) add(m Mutation) error {
+	if !bytes.Equal(this.key, m.row()) {
+		return fmt.Errorf("Mutation row must match the row of RowMutations [mutation: %s] [row: %s]", m.row(), this.key)
+	}
+
+	this.mutations = append(this.mutations, m)
+
+	return nil
+}
+
+// toProto returns the atomic region action, without its region.
+func (this *RowMutations) toProto() pb.Message {
+	actions := make([]*proto.Action, len(this.mutations))
+	for i, m := range this.mutations {
+		actions[i] = &proto.Action{
+			Index:    pb.Uint32(uint32(i)),
+			Mutation: m.toProto().(*proto.MutationProto),
+		}
+	}
+
+	return &proto.RegionAction{
+		Atomic: pb.Bool(true),
+		Action: actions,
+	}
+}
+
+// rowMutationsResult reports whether the mutations were applied, the
+// server fails the whole region action when one of them fails.
+func rowMutationsResult(r *proto.MultiResponse) (bool, error) {
+	for _, rar := range r.GetRegionActionResult() {
+		if e := rar.GetException(); e != nil {
+			return false, fmt.Errorf("Row mutations failed [exception: %s] [msg: %s]", e.GetName(), e.GetValue())
+		}
+
+		for _, roe := range rar.GetResultOrException() {
+			if e := roe.GetException(); e != nil {
+				return false, fmt.Errorf("Row mutations failed [exception: %s] [msg: %s]", e.GetName(), e.GetValue())
+			}
+		}
+	}
+
+	// only set when the mutations were conditional
+	if r.Processed != nil {
+		return r.GetProcessed(), nil
+	}
+
+	return true, nil
+}
@@ -2,6 +2,7 @@ package hbase
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"math"
 	"strings"
@@ -14,9 +15,10 @@ import (
 
 type Scan struct {
 	client *Client
+	ctx    context.Context
 
 	id    uint64
-	table []byte
+	table TableName
 
 	StartRow []byte
 	StopRow  []byte
@@ -28,9 +30,52 @@ type Scan struct {
 
 	numCached int
 	closed    bool
+	reversed  bool
+
+	// size controls, zero means the server default
+	maxResultSize uint64
+	batch         uint32
+	storeLimit    uint32
+	storeOffset   uint32
+	small         bool
+	// where a small scan continues inside its current region
+	smallStartRow []byte
+	// a reversed small scan continues at the last row it returned, which
+	// is dropped from the next step before it is buffered
+	smallSkipRow []byte
+
+	// rows fetched but not yet returned by Next
+	buffer []*ResultRow
+	err    error
+
+	// position of the scan for checkpoints
+	lastRow            []byte
+	skipRow            []byte
+	checkpointInterval int
+	checkpointFunc     func(*ScanCheckpoint)
+	sinceCheckpoint    int
+	// cells of lastRow returned so far, a batched scan may have returned
+	// only part of it
+	lastRowCells int
+
+	// a batched scan resumed inside partialRow skips its first skipCells
+	partialRow []byte
+	skipCells  int
+
+	// opt-in metrics, nil when disabled
+	metrics *ScanMetrics
+	started time.Time
+
+	retries  int
+	relocate bool
+
+	// region scans of a parallel scan stay in their pinned region
+	singleRegion bool
+	done         bool
 
 	//for filters
 	timeRange *TimeRange
+	filter    Filter
 
 	location *regionInfo
 	server   *connection
@@ -53,9 +98,10 @@ func init() {
 	maxTimestamp = time.Unix((math.MaxInt64-unixToInternal)/1e9, 0)
 }
 
-func newScan(table []byte, client *Client) *Scan {
+func newScan(ctx context.Context, table TableName, client *Client) *Scan {
 	return &Scan{
 		client:       client,
+		ctx:          ctx,
 		table:        table,
 		nextStartRow: nil,
 
@@ -96,32 +142,142 @@ func (s *Scan) SetTimeRangeTo(to time.Time) {
 	s.SetTimeRange(time.Unix(0, 0), to)
 }
 
+// scan from StartRow down to StopRow (exclusive), returning rows in
+// descending order. StartRow must be greater than StopRow, an empty
+// StartRow starts from the last row of the table.
+func (s *Scan) SetReversed(reversed bool) {
+	s.reversed = reversed
+}
+
+// set a server side filter for the scan
+func (s *Scan) SetFilter(filter Filter) {
+	s.filter = filter
+}
+
 func (s *Scan) SetCached(n int) {
 	s.numCached = n
 }
 
-func (s *Scan) Map(f func(*ResultRow)) {
+// SetMaxResultSize bounds the bytes returned by each RPC, the server
+// returns fewer rows than SetCached when they would not fit.
+func (s *Scan) SetMaxResultSize(n uint64) {
+	s.maxResultSize = n
+}
+
+// SetBatch returns wide rows in chunks of at most n cells, each chunk
+// being returned as its own (partial) ResultRow.
+func (s *Scan) SetBatch(n uint32) {
+	s.batch = n
+}
+
+// SetStoreLimit returns at most limit cells per row and column family.
+func (s *Scan) SetStoreLimit(limit uint32) {
+	s.storeLimit = limit
+}
+
+// SetStoreOffset skips the first offset cells of each row and column family.
+func (s *Scan) SetStoreOffset(offset uint32) {
+	s.storeOffset = offset
+}
+
+// SetSmall marks a scan of a few rows, which fetches each region in a
+// single RPC opening and closing the scanner at once. Set SetCached to
+// the expected number of rows. It is ignored for batched scans, and a
+// region returning fewer rows than SetCached, e.g. because of
+// SetMaxResultSize, is taken as exhausted.
+func (s *Scan) SetSmall(small bool) {
+	s.small = small
+}
+
+func (s *Scan) isSmall() bool {
+	return s.small && s.batch == 0
+}
+
+// Next returns the next row of the scan. It returns nil, nil once the
+// scan is exhausted or closed, and nil with the error if the scan failed,
+// after which the scan is closed and Err returns the same error.
+func (s *Scan) Next() (*ResultRow, error) {
+	if s.metrics != nil && s.started.IsZero() {
+		s.started = time.Now()
+	}
+
 	for {
-		results := s.next()
+		for len(s.buffer) == 0 {
+			if s.closed {
+				return nil, s.err
+			}
 
-		if results == nil {
-			break
+			results, err := s.next()
+			if err != nil {
+				s.err = err
+				s.Close()
+				return nil, err
+			}
+
+			if results == nil {
+				s.Close()
+				return nil, nil
+			}
+
+			s.buffer = results
 		}
 
-		for _, v := range results {
-			f(v)
+		r := s.buffer[0]
+		s.buffer = s.buffer[1:]
 
-			if s.closed {
-				return
+		// a resumed scan may see the last row of its checkpoint again
+		if s.skipRow != nil {
+			skip := bytes.Equal(r.Row, s.skipRow)
+			s.skipRow = nil
+			if skip {
+				continue
 			}
 		}
+
+		s.rowReturned(r)
+
+		return r, nil
+	}
+}
+
+// Err returns the error which stopped the scan, if any.
+func (s *Scan) Err() error {
+	return s.err
+}
+
+// Map calls f for every row of the scan. It stops at the first error,
+// which is then available from Err, or when f closes the scan.
+func (s *Scan) Map(f func(*ResultRow)) {
+	for {
+		r, err := s.Next()
+		if err != nil {
+			dlog.Error("Scan stopped on error: %v", err)
+			return
+		}
+
+		if r == nil {
+			return
+		}
+
+		f(r)
+
+		if s.closed {
+			return
+		}
 	}
 }
 
 func (s *Scan) Close() {
 	if s.closed == false {
-		s.closeScan(s.server, s.location, s.id)
+		if s.metrics != nil && !s.started.IsZero() {
+			s.metrics.LocalTime += time.Since(s.started) - s.metrics.RemoteTime
+		}
+
+		if s.server != nil && s.location != nil && s.id > 0 {
+			s.closeScan(s.server, s.location, s.id)
+		}
 		s.closed = true
+		s.buffer = nil
 	}
 }
 
@@ -173,12 +329,60 @@ func (s *Scan) posOfFamily(family []byte) int {
 	return -1
 }
 
-func (s *Scan) getData(nextStart []byte) []*ResultRow {
-	if s.closed {
-		return nil
+// scanProto describes the scan for opening a scanner.
+func (s *Scan) scanProto() *proto.Scan {
+	scan := &proto.Scan{}
+
+	if s.StartRow != nil {
+		scan.StartRow = s.StartRow
+	}
+	if s.StopRow != nil {
+		scan.StopRow = s.StopRow
+	}
+	if s.timeRange != nil {
+		scan.TimeRange = s.timeRange.toProto()
+	}
+	if s.filter != nil {
+		scan.Filter = s.filter.toProto()
+	}
+	if s.reversed {
+		scan.Reversed = pb.Bool(true)
+	}
+	if s.maxResultSize > 0 {
+		scan.MaxResultSize = pb.Uint64(s.maxResultSize)
+	}
+	if s.batch > 0 {
+		scan.BatchSize = pb.Uint32(s.batch)
+	}
+	if s.storeLimit > 0 {
+		scan.StoreLimit = pb.Uint32(s.storeLimit)
+	}
+	if s.storeOffset > 0 {
+		scan.StoreOffset = pb.Uint32(s.storeOffset)
+	}
+	if s.isSmall() {
+		scan.Small = pb.Bool(true)
+	}
+
+	for i, v := range s.families {
+		scan.Column = append(scan.Column, &proto.Column{
+			Family:    v,
+			Qualifier: s.qualifiers[i],
+		})
+	}
+
+	return scan
+}
+
+func (s *Scan) getData(nextStart []byte) ([]*ResultRow, error) {
+	if s.closed || s.done {
+		return nil, nil
 	}
 
-	server, location := s.getServerAndLocation(s.table, nextStart)
+	server, location, err := s.getServerAndLocation(s.table, nextStart)
+	if err != nil {
+		return nil, err
+	}
 
 	req := &proto.ScanRequest{
 		Region: &proto.RegionSpecifier{
@@ -186,72 +390,172 @@ func (s *Scan) getData(nextStart []byte) []*ResultRow {
 			Value: []byte(location.name),
 		},
 		NumberOfRows: pb.Uint32(uint32(s.numCached)),
-		Scan:         &proto.Scan{},
 	}
 
 	if s.id > 0 {
 		req.ScannerId = pb.Uint64(s.id)
 	} else {
-		if s.StartRow != nil {
-			req.Scan.StartRow = s.StartRow
-		}
-		if s.StopRow != nil {
-			req.Scan.StopRow = s.StopRow
-		}
-		if s.timeRange != nil {
-			req.Scan.TimeRange = &proto.TimeRange{
-				From: pb.Uint64(uint64(s.timeRange.From.UnixNano() / 1e6)),
-				To:   pb.Uint64(uint64(s.timeRange.To.UnixNano() / 1e6)),
+		req.Scan = s.scanProto()
+		if s.isSmall() {
+			req.CloseScanner = pb.Bool(true)
+			if s.smallStartRow != nil {
+				req.Scan.StartRow = s.smallStartRow
+			}
+			if s.smallSkipRow != nil {
+				// one more row for the one dropped
+				req.NumberOfRows = pb.Uint32(uint32(s.numCached + 1))
 			}
 		}
 	}
 
-	for i, v := range s.families {
-		req.Scan.Column = append(req.Scan.Column, &proto.Column{
-			Family:    v,
-			Qualifier: s.qualifiers[i],
-		})
+	opening := s.id == 0
+	if opening && s.metrics != nil && s.retries == 0 && s.smallStartRow == nil {
+		s.metrics.RegionsScanned++
 	}
 
 	cl := newCall(req)
-	server.call(cl)
+	err = server.call(cl)
+	if err != nil {
+		// purge dead server
+		s.client.purgeServer(location.server)
+		s.server = nil
+		s.location = nil
+		s.id = 0
+		if opening && s.retryOpen() {
+			return s.getData(nextStart)
+		}
+		return nil, fmt.Errorf("Scan call failed [region: %s] [err: %v]", location.name, err)
+	}
+
+	if s.metrics != nil {
+		s.metrics.RPCCalls++
+	}
+
+	start := time.Now()
+	msg := server.wait(s.ctx, cl)
+	if s.metrics != nil {
+		s.metrics.RemoteTime += time.Since(start)
+	}
+
+	if _, ok := msg.(*exception); ok {
+		if s.ctx.Err() != nil {
+			return nil, s.ctx.Err()
+		}
 
-	select {
-	case msg := <-cl.responseCh:
-		return s.processResponse(msg)
+		if opening && s.retryOpen() {
+			dlog.Info("exception retrying scan of region %s for the %d time", location.name, s.retries)
+			s.server = nil
+			s.location = nil
+			return s.getData(nextStart)
+		}
 	}
+
+	s.retries = 0
+
+	return s.processResponse(msg)
 }
 
-var lastRegionRows int = 0
+// retryOpen tells whether opening a scanner which failed can be retried,
+// nothing was read from the region yet so no row is returned twice.
+func (s *Scan) retryOpen() bool {
+	if s.singleRegion || s.retries >= s.client.maxRetries {
+		return false
+	}
+
+	s.retries++
+	s.relocate = true
+	if s.metrics != nil {
+		s.metrics.Retries++
+	}
+
+	return true
+}
 
-func (s *Scan) processResponse(response pb.Message) []*ResultRow {
+func (s *Scan) processResponse(response pb.Message) ([]*ResultRow, error) {
 	var res *proto.ScanResponse
 	switch r := response.(type) {
 	case *proto.ScanResponse:
 		res = r
+	case *exception:
+		// the region may have moved or the scanner expired, nothing to close
+		s.server = nil
+		s.location = nil
+		s.id = 0
+		return nil, fmt.Errorf("Scan failed [table: %s] [err: %s]", s.table, r.msg)
 	default:
-		dlog.Error("Invalid response returned: %T", response)
-		return nil
+		return nil, fmt.Errorf("Invalid response returned: %T", response)
+	}
+
+	results := res.GetResults()
+	n := len(results)
+
+	if s.metrics != nil {
+		for _, r := range results {
+			for _, cell := range r.GetCell() {
+				s.metrics.BytesReturned += int64(len(cell.GetRow()) + len(cell.GetFamily()) + len(cell.GetQualifier()) + len(cell.GetValue()))
+			}
+		}
+	}
+
+	tbr := make([]*ResultRow, 0, n)
+	for i, v := range results {
+		if s.partialRow != nil {
+			if v = s.skipPartialCells(v); v == nil {
+				continue
+			}
+		}
+
+		r := newResultRow(v)
+		if i == 0 && s.smallSkipRow != nil && bytes.Equal(r.Row, s.smallSkipRow) {
+			continue
+		}
+		tbr = append(tbr, r)
+	}
+
+	if s.isSmall() {
+		asked := s.numCached
+		if s.smallSkipRow != nil {
+			asked++
+		}
+		s.smallSkipRow = nil
+
+		s.processSmallResponse(tbr, n == asked)
+		return tbr, nil
 	}
 
 	nextRegion := true
 	s.nextStartRow = nil
 	s.id = res.GetScannerId()
 
-	results := res.GetResults()
-	n := len(results)
+	if s.reversed {
+		if (n == s.numCached) ||
+			len(s.location.startKey) == 0 ||
+			(len(s.StopRow) > 0 && bytes.Compare(s.StopRow, s.location.startKey) >= 0) ||
+			(res.GetMoreResults() && n > 0) {
+			nextRegion = false
+		}
 
-	lastRegionRows += n
+		if n < s.numCached && len(s.location.startKey) > 0 {
+			s.nextStartRow = closestRowBefore(s.location.startKey)
+		}
+	} else {
+		if (n == s.numCached) ||
+			len(s.location.endKey) == 0 ||
+			(len(s.StopRow) > 0 && bytes.Compare(s.location.endKey, s.StopRow) >= 0 && n < s.numCached) ||
+			(res.GetMoreResults() && n > 0) {
+			nextRegion = false
+		}
 
-	if (n == s.numCached) ||
-		len(s.location.endKey) == 0 ||
-		(s.StopRow != nil && bytes.Compare(s.location.endKey, s.StopRow) > 0 && n < s.numCached) ||
-		(res.GetMoreResults() && n > 0) {
-		nextRegion = false
+		if n < s.numCached {
+			s.nextStartRow = incrementByteString(s.location.endKey, len(s.location.endKey)-1)
+		}
 	}
 
-	if n < s.numCached {
-		s.nextStartRow = incrementByteString(s.location.endKey, len(s.location.endKey)-1)
+	if nextRegion && s.singleRegion {
+		s.closeScan(s.server, s.location, s.id)
+		s.id = 0
+		s.done = true
+		nextRegion = false
 	}
 
 	if nextRegion {
@@ -259,22 +563,63 @@ func (s *Scan) processResponse(response pb.Message) []*ResultRow {
 		s.server = nil
 		s.location = nil
 		s.id = 0
-		lastRegionRows = 0
 	}
 
 	if n == 0 && !nextRegion {
 		s.Close()
 	}
 
-	tbr := make([]*ResultRow, n)
-	for i, v := range results {
-		tbr[i] = newResultRow(v)
+	return tbr, nil
+}
+
+// processSmallResponse moves a small scan along, its scanner was already
+// closed by the server so each step opens a new one. full tells whether
+// the step returned all the rows asked for.
+func (s *Scan) processSmallResponse(rows []*ResultRow, full bool) {
+	n := len(rows)
+	s.id = 0
+
+	// like Java's small scanner, a region which returned fewer rows than
+	// asked for is done, asking again would cost an RPC per region
+	if n > 0 && full {
+		// more rows in this region, continue after the last one
+		last := rows[n-1].Row
+		if s.reversed {
+			s.smallStartRow = last
+			s.smallSkipRow = last
+		} else {
+			s.smallStartRow = append(append(make([]byte, 0, len(last)+1), last...), 0)
+		}
+		return
+	}
+
+	s.smallStartRow = nil
+
+	var lastRegion bool
+	if s.reversed {
+		lastRegion = len(s.location.startKey) == 0 ||
+			(len(s.StopRow) > 0 && bytes.Compare(s.StopRow, s.location.startKey) >= 0)
+	} else {
+		lastRegion = len(s.location.endKey) == 0 ||
+			(len(s.StopRow) > 0 && bytes.Compare(s.location.endKey, s.StopRow) >= 0)
+	}
+
+	if lastRegion || s.singleRegion {
+		s.done = true
+		return
 	}
 
-	return tbr
+	if s.reversed {
+		s.nextStartRow = closestRowBefore(s.location.startKey)
+	} else {
+		s.nextStartRow = incrementByteString(s.location.endKey, len(s.location.endKey)-1)
+	}
+
+	s.server = nil
+	s.location = nil
 }
 
-func (s *Scan) next() []*ResultRow {
+func (s *Scan) next() ([]*ResultRow, error) {
 	startRow := s.nextStartRow
 	if startRow == nil {
 		startRow = s.StartRow
@@ -294,19 +639,55 @@ func (s *Scan) closeScan(server *connection, location *regionInfo, id uint64) {
 		CloseScanner: pb.Bool(true),
 	}
 	cl := newCall(req)
-	server.call(cl)
-	<-cl.responseCh
+	if err := server.call(cl); err != nil {
+		return
+	}
+
+	// the scan context may already be done, closing has its own deadline
+	ctx, cancel := context.WithTimeout(context.Background(), call_timeout*time.Millisecond)
+	defer cancel()
+	server.wait(ctx, cl)
 }
 
-func (s *Scan) getServerAndLocation(table, startRow []byte) (server *connection, location *regionInfo) {
+func (s *Scan) getServerAndLocation(table TableName, startRow []byte) (server *connection, location *regionInfo, err error) {
 	if s.server != nil && s.location != nil {
 		server = s.server
 		location = s.location
 		return
 	}
 
-	location = s.client.locateRegion(table, startRow, true)
-	server = s.client.getRegionConnection(location.server)
+	if s.singleRegion {
+		location = s.location
+		server, err = s.client.getRegionConnection(location.server)
+		s.server = server
+		return
+	}
+
+	if s.reversed && len(startRow) == 0 {
+		location = s.client.locateLastRegion(s.ctx, table)
+	} else {
+		location, err = s.client.locateRegion(s.ctx, table, startRow, !s.relocate)
+		s.relocate = false
+	}
+
+	if s.ctx.Err() != nil {
+		err = s.ctx.Err()
+		return
+	}
+
+	if err != nil {
+		return
+	}
+
+	if location == nil {
+		err = fmt.Errorf("Unable to locate region [table: %s] [row: %q]", table, startRow)
+		return
+	}
+
+	server, err = s.client.getRegionConnection(location.server)
+	if err != nil {
+		return
+	}
 
 	s.server = server
 	s.location = location
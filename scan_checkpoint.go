@@ -0,0 +1,229 @@
+package hbase
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/cugbliwei/go-hbase/proto"
+	pb "github.com/golang/protobuf/proto"
+)
+
+// ScanCheckpoint is the serializable position of a scan: its spec and the
+// last row it returned. A scan resumed from it continues right after LastRow,
+// or for batched scans right after the LastRowCells cells of LastRow.
+type ScanCheckpoint struct {
+	Table string
+
+	StartRow []byte
+	StopRow  []byte
+	LastRow  []byte
+	// cells of LastRow already returned by a batched scan
+	LastRowCells int
+
+	Families   [][]byte
+	Qualifiers [][][]byte
+
+	TimeRange *TimeRange
+	Reversed  bool
+	NumCached int
+
+	MaxResultSize uint64
+	Batch         uint32
+	StoreLimit    uint32
+	StoreOffset   uint32
+	Small         bool
+
+	// serialized proto.Filter
+	Filter []byte
+}
+
+// Checkpoint returns the current position of the scan. Batched scans
+// resume inside the last row, a wide row may have been partly returned.
+func (s *Scan) Checkpoint() *ScanCheckpoint {
+	cp := &ScanCheckpoint{
+		Table:      s.table.String(),
+		StartRow:   s.StartRow,
+		StopRow:    s.StopRow,
+		LastRow:    s.lastRow,
+		Families:   s.families,
+		Qualifiers: s.qualifiers,
+		Reversed:   s.reversed,
+		NumCached:  s.numCached,
+
+		MaxResultSize: s.maxResultSize,
+		Batch:         s.batch,
+		StoreLimit:    s.storeLimit,
+		StoreOffset:   s.storeOffset,
+		Small:         s.small,
+	}
+
+	if s.batch > 0 {
+		cp.LastRowCells = s.lastRowCells
+	}
+
+	if s.timeRange != nil {
+		cp.TimeRange = &TimeRange{
+			From: s.timeRange.From,
+			To:   s.timeRange.To,
+		}
+	}
+
+	if s.filter != nil {
+		cp.Filter, _ = pb.Marshal(s.filter.toProto())
+	}
+
+	return cp
+}
+
+// SetCheckpointInterval calls f with the checkpoint of the scan every n rows.
+func (s *Scan) SetCheckpointInterval(n int, f func(*ScanCheckpoint)) {
+	s.checkpointInterval = n
+	s.checkpointFunc = f
+	s.sinceCheckpoint = 0
+}
+
+func (s *Scan) rowReturned(r *ResultRow) {
+	if bytes.Equal(r.Row, s.lastRow) {
+		s.lastRowCells += len(r.Cells)
+	} else {
+		s.lastRow = r.Row
+		s.lastRowCells = len(r.Cells)
+	}
+
+	if s.metrics != nil {
+		s.metrics.RowsReturned++
+	}
+
+	if s.checkpointInterval <= 0 || s.checkpointFunc == nil {
+		return
+	}
+
+	s.sinceCheckpoint++
+	if s.sinceCheckpoint >= s.checkpointInterval {
+		s.sinceCheckpoint = 0
+		s.checkpointFunc(s.Checkpoint())
+	}
+}
+
+// Encode serializes the checkpoint to an url safe token.
+func (cp *ScanCheckpoint) Encode() (string, error) {
+	b, err := json.Marshal(cp)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func DecodeScanCheckpoint(token string) (*ScanCheckpoint, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid scan checkpoint token [err: %v]", err)
+	}
+
+	var cp ScanCheckpoint
+	if err := json.Unmarshal(b, &cp); err != nil {
+		return nil, fmt.Errorf("Invalid scan checkpoint token [err: %v]", err)
+	}
+
+	return &cp, nil
+}
+
+// ResumeScan returns a scan continuing after the last row of cp.
+func (c *Client) ResumeScan(cp *ScanCheckpoint) (*Scan, error) {
+	return c.ResumeScanContext(context.Background(), cp)
+}
+
+func (c *Client) ResumeScanContext(ctx context.Context, cp *ScanCheckpoint) (*Scan, error) {
+	if len(cp.Families) != len(cp.Qualifiers) {
+		return nil, fmt.Errorf("Invalid scan checkpoint, %d families for %d qualifier lists", len(cp.Families), len(cp.Qualifiers))
+	}
+
+	s := newScan(ctx, ParseTableName(cp.Table), c)
+
+	s.StartRow = cp.StartRow
+	s.StopRow = cp.StopRow
+	s.families = cp.Families
+	s.qualifiers = cp.Qualifiers
+	s.reversed = cp.Reversed
+	s.lastRow = cp.LastRow
+	s.maxResultSize = cp.MaxResultSize
+	s.batch = cp.Batch
+	s.storeLimit = cp.StoreLimit
+	s.storeOffset = cp.StoreOffset
+	s.small = cp.Small
+
+	if cp.NumCached > 0 {
+		s.numCached = cp.NumCached
+	}
+
+	if cp.TimeRange != nil {
+		s.SetTimeRange(cp.TimeRange.From, cp.TimeRange.To)
+	}
+
+	if len(cp.Filter) > 0 {
+		var f proto.Filter
+		if err := pb.Unmarshal(cp.Filter, &f); err != nil {
+			return nil, fmt.Errorf("Invalid scan checkpoint filter [err: %v]", err)
+		}
+		s.filter = &rawFilter{&f}
+	}
+
+	if cp.LastRow != nil {
+		if cp.LastRowCells > 0 {
+			// the row may have more cells, restart on it and skip the
+			// ones already returned
+			s.StartRow = cp.LastRow
+			s.lastRowCells = cp.LastRowCells
+			s.partialRow = cp.LastRow
+			s.skipCells = cp.LastRowCells
+		} else if cp.Reversed {
+			// no row sorts right before LastRow, restart on it and skip it
+			s.StartRow = cp.LastRow
+			s.skipRow = cp.LastRow
+		} else {
+			s.StartRow = append(append(make([]byte, 0, len(cp.LastRow)+1), cp.LastRow...), 0)
+		}
+	}
+
+	return s, nil
+}
+
+// skipPartialCells drops the cells a resumed batched scan already returned
+// from result, nil when none are left.
+func (s *Scan) skipPartialCells(result *proto.Result) *proto.Result {
+	cells := result.GetCell()
+	if len(cells) == 0 || !bytes.Equal(cells[0].GetRow(), s.partialRow) {
+		s.partialRow = nil
+		s.skipCells = 0
+		return result
+	}
+
+	k := s.skipCells
+	if k > len(cells) {
+		k = len(cells)
+	}
+
+	s.skipCells -= k
+	if s.skipCells == 0 {
+		s.partialRow = nil
+	}
+
+	if k == len(cells) {
+		return nil
+	}
+
+	return &proto.Result{Cell: cells[k:]}
+}
+
+// rawFilter is an already serialized filter.
+type rawFilter struct {
+	filter *proto.Filter
+}
+
+func (this *rawFilter) toProto() *proto.Filter {
+	return this.filter
+}
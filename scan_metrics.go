@@ -0,0 +1,53 @@
+package hbase
+
+import (
+	"time"
+)
+
+// ScanMetrics are collected on the client side by scans which enabled them.
+// There is no count of the rows filtered out: the region servers collect
+// it, but the ScanRequest and ScanResponse of this protocol version have
+// no fields to ask for and return their metrics.
+type ScanMetrics struct {
+	// scanner RPCs sent to region servers, opening and fetching
+	RPCCalls       int64
+	RegionsScanned int64
+	// scanner opens retried after a region moved or a server failed
+	Retries int64
+
+	RowsReturned int64
+	// sum of the row, family, qualifier and value sizes of returned cells
+	BytesReturned int64
+
+	// time spent waiting on region servers
+	RemoteTime time.Duration
+	// time spent in the client between RPCs, including the caller's
+	// processing of rows
+	LocalTime time.Duration
+}
+
+// Add accumulates o into m, e.g. the metrics of the regions of a parallel scan.
+func (m *ScanMetrics) Add(o *ScanMetrics) {
+	m.RPCCalls += o.RPCCalls
+	m.RegionsScanned += o.RegionsScanned
+	m.Retries += o.Retries
+	m.RowsReturned += o.RowsReturned
+	m.BytesReturned += o.BytesReturned
+	m.RemoteTime += o.RemoteTime
+	m.LocalTime += o.LocalTime
+}
+
+// SetMetricsEnabled turns metrics collection on or off, before the scan starts.
+func (s *Scan) SetMetricsEnabled(enabled bool) {
+	if enabled {
+		s.metrics = &ScanMetrics{}
+	} else {
+		s.metrics = nil
+	}
+}
+
+// Metrics returns the metrics of the scan, complete once it is closed or
+// exhausted, or nil when they are not enabled.
+func (s *Scan) Metrics() *ScanMetrics {
+	return s.metrics
+}
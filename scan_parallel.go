@@ -0,0 +1,188 @@
+package hbase
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+)
+
+// ScanResult is a row of a parallel scan, or the error which stopped
+// the scan of one region.
+type ScanResult struct {
+	Row    *ResultRow
+	Region string
+	Err    error
+}
+
+// Parallel runs the scan with one scanner per region overlapping the
+// scanned range, at most concurrency of them at a time. Rows are sent on
+// the returned channel, which is closed once every region is done.
+//
+// When ordered is set rows are sent in the order a sequential scan would
+// return them, otherwise as soon as they are fetched. A failed region is
+// reported once with Err set while the other regions carry on. When the
+// regions of the scan cannot be looked up, the only result is the error.
+// The channel must be drained, cancel the scan context to stop early.
+func (s *Scan) Parallel(concurrency int, ordered bool) <-chan *ScanResult {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	regions, err := s.parallelRegions()
+	if err == nil && len(regions) == 0 {
+		if err = s.ctx.Err(); err == nil {
+			err = fmt.Errorf("No regions found [table: %s] [start: %q] [stop: %q]", s.table, s.StartRow, s.StopRow)
+		}
+	}
+
+	if err != nil {
+		out := make(chan *ScanResult, 1)
+		out <- &ScanResult{Err: err}
+		close(out)
+		return out
+	}
+
+	out := make(chan *ScanResult, s.numCached)
+	sem := make(chan bool, concurrency)
+
+	chs := make([]chan *ScanResult, len(regions))
+	for i := range regions {
+		if ordered {
+			chs[i] = make(chan *ScanResult, s.numCached)
+		} else {
+			chs[i] = out
+		}
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(len(regions))
+
+	metricsLock := &sync.Mutex{}
+
+	// regions acquire their slot in order, so the region an ordered
+	// scan is waiting on always gets to run
+	go func() {
+		for i, region := range regions {
+			sem <- true
+
+			go func(rs *Scan, ch chan *ScanResult) {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				rs.runRegion(ch)
+
+				if rs.metrics != nil {
+					metricsLock.Lock()
+					s.metrics.Add(rs.metrics)
+					metricsLock.Unlock()
+				}
+
+				if ordered {
+					close(ch)
+				}
+			}(s.regionScan(region), chs[i])
+		}
+	}()
+
+	if ordered {
+		go func() {
+			for _, ch := range chs {
+				for r := range ch {
+					out <- r
+				}
+			}
+			close(out)
+		}()
+	} else {
+		go func() {
+			wg.Wait()
+			close(out)
+		}()
+	}
+
+	return out
+}
+
+// parallelRegions returns the regions overlapping the scanned range, in
+// the order the scan visits them.
+func (s *Scan) parallelRegions() ([]*regionInfo, error) {
+	if !s.reversed {
+		return s.client.regionsInRange(s.ctx, s.table, s.StartRow, s.StopRow)
+	}
+
+	// reversed scans cover (StopRow, StartRow]
+	var after []byte
+	if len(s.StartRow) > 0 {
+		after = append(append(make([]byte, 0, len(s.StartRow)+1), s.StartRow...), 0)
+	}
+
+	regions, err := s.client.regionsInRange(s.ctx, s.table, s.StopRow, after)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, j := 0, len(regions)-1; i < j; i, j = i+1, j-1 {
+		regions[i], regions[j] = regions[j], regions[i]
+	}
+
+	return regions, nil
+}
+
+func (s *Scan) runRegion(ch chan *ScanResult) {
+	defer s.Close()
+
+	region := s.location.name
+
+	for {
+		r, err := s.Next()
+		if err != nil {
+			ch <- &ScanResult{Region: region, Err: err}
+			return
+		}
+
+		if r == nil {
+			return
+		}
+
+		ch <- &ScanResult{Row: r, Region: region}
+	}
+}
+
+// regionScan returns a scan of the same spec pinned to region.
+func (s *Scan) regionScan(region *regionInfo) *Scan {
+	rs := newScan(s.ctx, s.table, s.client)
+
+	rs.StartRow = s.StartRow
+	rs.StopRow = s.StopRow
+
+	if !s.reversed {
+		// clamp to the region, reversed scans are clamped by the server
+		if len(region.startKey) > 0 && bytes.Compare(rs.StartRow, region.startKey) < 0 {
+			rs.StartRow = region.startKey
+		}
+		if len(region.endKey) > 0 && (len(rs.StopRow) == 0 || bytes.Compare(region.endKey, rs.StopRow) < 0) {
+			rs.StopRow = region.endKey
+		}
+	}
+
+	rs.families = s.families
+	rs.qualifiers = s.qualifiers
+	rs.numCached = s.numCached
+	rs.maxResultSize = s.maxResultSize
+	rs.batch = s.batch
+	rs.storeLimit = s.storeLimit
+	rs.storeOffset = s.storeOffset
+	rs.small = s.small
+	rs.reversed = s.reversed
+	rs.timeRange = s.timeRange
+	rs.filter = s.filter
+
+	rs.location = region
+	rs.singleRegion = true
+
+	if s.metrics != nil {
+		rs.metrics = &ScanMetrics{}
+	}
+
+	return rs
+}
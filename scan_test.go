@@ -0,0 +1,73 @@
+package hbase
+
+import (
+	"testing"
+
+	"github.com/cugbliwei/go-hbase/proto"
+)
+
+func scanResponse(rows ...string) *proto.ScanResponse {
+	res := &proto.ScanResponse{}
+	for _, row := range rows {
+		c := newTestCell(row, "cf", "q", 1, CellPut, row)
+		res.Results = append(res.Results, &proto.Result{Cell: []*proto.Cell{c.ToProto()}})
+	}
+
+	return res
+}
+
+func rowKeys(rows []*ResultRow) []string {
+	keys := make([]string, len(rows))
+	for i, r := range rows {
+		keys[i] = r.Row.String()
+	}
+
+	return keys
+}
+
+func TestReversedSmallScanSteps(t *testing.T) {
+	tests := []struct {
+		cached int
+		steps  [][]string
+		want   [][]string
+	}{
+		{
+			cached: 2,
+			steps:  [][]string{{"r9", "r8"}, {"r8", "r7", "r6"}, {"r6", "r5"}},
+			want:   [][]string{{"r9", "r8"}, {"r7", "r6"}, {"r5"}},
+		},
+		{
+			cached: 1,
+			steps:  [][]string{{"r9"}, {"r9", "r8"}, {"r8"}},
+			want:   [][]string{{"r9"}, {"r8"}, {}},
+		},
+	}
+
+	for _, tt := range tests {
+		s := &Scan{
+			numCached: tt.cached,
+			reversed:  true,
+			small:     true,
+			location:  &regionInfo{startKey: []byte{}, endKey: []byte{}},
+		}
+
+		for i, step := range tt.steps {
+			if s.done {
+				t.Fatalf("cached %d: scan done before step %d", tt.cached, i)
+			}
+
+			rows, err := s.processResponse(scanResponse(step...))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := rowKeys(rows); !equalStrings(got, tt.want[i]) {
+				t.Errorf("cached %d: step %d returned %v, want %v", tt.cached, i, got, tt.want[i])
+			}
+		}
+
+		if !s.done {
+			t.Errorf("cached %d: scan not done after the last step", tt.cached)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package hbase
+
+import (
+	"strings"
+
+	"github.com/cugbliwei/go-hbase/proto"
+)
+
+const (
+	default_namespace = "default"
+	system_namespace  = "hbase"
+)
+
+// TableName is a table in its namespace. Tables created without a
+// namespace are in the "default" one.
+type TableName struct {
+	Namespace string
+	Qualifier string
+}
+
+func NewTableName(namespace, qualifier string) TableName {
+	if namespace == "" {
+		namespace = default_namespace
+	}
+
+	return TableName{
+		Namespace: namespace,
+		Qualifier: qualifier,
+	}
+}
+
+// ParseTableName parses "namespace:table", or "table" in the default namespace.
+func ParseTableName(name string) TableName {
+	if i := strings.IndexByte(name, ':'); i != -1 {
+		return NewTableName(name[:i], name[i+1:])
+	}
+
+	return NewTableName(default_namespace, name)
+}
+
+func tableNameFromProto(t *proto.TableName) TableName {
+	return NewTableName(string(t.GetNamespace()), string(t.GetQualifier()))
+}
+
+// String is the name used in region names, the namespace is left out
+// for the default namespace.
+func (t TableName) String() string {
+	if t.Namespace == "" || t.Namespace == default_namespace {
+		return t.Qualifier
+	}
+
+	return t.Namespace + ":" + t.Qualifier
+}
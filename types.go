@@ -1,19 +1,20 @@
 package hbase
 
 import (
+	"strings"
 	"time"
 
+	"github.com/cugbliwei/go-hbase/proto"
 	pb "github.com/golang/protobuf/proto"
 )
 
 type regionInfo struct {
-	server         string
-	startKey       []byte
-	endKey         []byte
-	name           string
-	ts             string
-	tableNamespace string
-	tableName      string
+	server   string
+	startKey []byte
+	endKey   []byte
+	name     string
+	ts       string
+	table    TableName
 }
 
 type action interface {
@@ -28,12 +29,70 @@ func (m *exception) Reset()         { *m = exception{} }
 func (m *exception) String() string { return m.msg }
 func (*exception) ProtoMessage()    {}
 
+// call_not_sent_exception names the exception of a call which could not
+// be sent to the server, so none of it was applied.
+const call_not_sent_exception = "CallNotSent"
+
+// local_exception names the exceptions raised by the client once a call
+// was sent, e.g. for a response which cannot be decoded. The server may
+// have applied the call.
+const local_exception = "local"
+
+// exceptionName returns the Java class of an exception thrown by the
+// server, or local_exception if the call failed on the client side.
+func exceptionName(msg string) string {
+	if !strings.HasPrefix(msg, server_exception_prefix) {
+		return local_exception
+	}
+
+	name := strings.TrimPrefix(msg, server_exception_prefix)
+	if i := strings.IndexByte(name, '\n'); i != -1 {
+		name = name[:i]
+	}
+
+	return name
+}
+
+// regionMovedExceptions are thrown for actions sent to a region which is
+// not served where it was located, relocating it and retrying may succeed.
+var regionMovedExceptions = []string{
+	"org.apache.hadoop.hbase.NotServingRegionException",
+	"org.apache.hadoop.hbase.exceptions.RegionMovedException",
+	"org.apache.hadoop.hbase.exceptions.RegionOpeningException",
+}
+
+// regionMoved tells whether the exception message or class name is one
+// of regionMovedExceptions.
+func regionMoved(msg string) bool {
+	for _, name := range regionMovedExceptions {
+		if strings.Contains(msg, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
 type TableInfo struct {
+	Namespace string
 	TableName string
 	Families  []string
 }
 
+// Name is the namespace qualified name of the table.
+func (t TableInfo) Name() TableName {
+	return NewTableName(t.Namespace, t.TableName)
+}
+
 type TimeRange struct {
 	From time.Time
 	To   time.Time
 }
+
+// hbase time ranges are in milliseconds, [From, To)
+func (t *TimeRange) toProto() *proto.TimeRange {
+	return &proto.TimeRange{
+		From: pb.Uint64(uint64(t.From.UnixNano() / 1e6)),
+		To:   pb.Uint64(uint64(t.To.UnixNano() / 1e6)),
+	}
+}
@@ -43,6 +43,22 @@ func incrementByteString(d []byte, i int) []byte {
 	return r
 }
 
+// closestRowBefore returns a row sorting just before d, used to step
+// into the previous region. d must not be empty.
+func closestRowBefore(d []byte) []byte {
+	last := len(d) - 1
+	if d[last] == 0 {
+		r := make([]byte, last)
+		copy(r, d)
+		return r
+	}
+
+	r := make([]byte, len(d), len(d)+9)
+	copy(r, d)
+	r[last]--
+	return append(r, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff)
+}
+
 func merge(cs ...chan pb.Message) chan pb.Message {
 	var wg sync.WaitGroup
 	out := make(chan pb.Message)
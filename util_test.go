@@ -0,0 +1,39 @@
+package hbase
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestClosestRowBefore(t *testing.T) {
+	tests := []struct {
+		row  []byte
+		want []byte
+	}{
+		{[]byte{0x00}, []byte{}},
+		{[]byte("a\x00"), []byte("a")},
+		{[]byte("b"), []byte("a\xff\xff\xff\xff\xff\xff\xff\xff\xff")},
+		{[]byte("ab"), []byte("aa\xff\xff\xff\xff\xff\xff\xff\xff\xff")},
+		{[]byte{0x01}, []byte{0x00, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+	}
+
+	for _, tt := range tests {
+		got := closestRowBefore(tt.row)
+		if !bytes.Equal(got, tt.want) {
+			t.Errorf("closestRowBefore(%q) = %q, want %q", tt.row, got, tt.want)
+		}
+
+		if bytes.Compare(got, tt.row) >= 0 {
+			t.Errorf("closestRowBefore(%q) = %q does not sort before it", tt.row, got)
+		}
+	}
+}
+
+func TestClosestRowBeforeKeepsInput(t *testing.T) {
+	row := []byte("ab")
+	closestRowBefore(row)
+
+	if string(row) != "ab" {
+		t.Errorf("closestRowBefore modified its input to %q", row)
+	}
+}